@@ -3,15 +3,16 @@ package esms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
-	"github.com/spf13/viper"
 )
 
 const (
@@ -26,8 +27,16 @@ const (
 
 	// ESMSVoiceOTPEndpoint is the endpoint for sending voice OTP
 	ESMSVoiceOTPEndpoint = "/voice/otp"
+
+	// ESMSSendStatusEndpoint is the endpoint for looking up the send status of a message
+	ESMSSendStatusEndpoint = "/sms/status"
 )
 
+func init() {
+	// Make the provider available to sms.NewModuleFromConfig
+	sms.MustRegisterProviderFactory(ProviderName, NewProviderFromConfig)
+}
+
 // ESMS API response structures
 type esmsSMSResponse struct {
 	CodeResult      string `json:"CodeResult"`
@@ -36,6 +45,15 @@ type esmsSMSResponse struct {
 	SMSID           string `json:"SMSID"`
 }
 
+type esmsStatusResponse struct {
+	CodeResult   string `json:"CodeResult"`
+	ErrorMessage string `json:"ErrorMessage"`
+	SMSID        string `json:"SMSID"`
+	SendStatus   int    `json:"SendStatus"`
+	SendSuccess  int    `json:"SendSuccess"`
+	SendFailed   int    `json:"SendFailed"`
+}
+
 type esmsVoiceResponse struct {
 	CodeResult   string `json:"CodeResult"`
 	ErrorMessage string `json:"ErrorMessage"`
@@ -59,25 +77,58 @@ func NewProvider(configFile string) (model.Provider, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Create a new Viper instance
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+	return NewProviderFromConfig(cfg, nil)
+}
 
+// NewProviderFromConfig creates a eSMS provider from the providers.esms section of an
+// already parsed configuration. If httpClient is nil, a client is created from cfg.
+func NewProviderFromConfig(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
 	// Load eSMS-specific configuration
-	esmsConfig, err := LoadConfig(v)
+	esmsConfig, err := ConfigFromProvider(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load eSMS configuration: %w", err)
 	}
 
+	if httpClient == nil {
+		return NewProviderWithConfig(esmsConfig, cfg)
+	}
+	return NewProviderWithClient(esmsConfig, httpClient)
+}
+
+// NewProviderWithConfig creates a eSMS provider from a typed configuration.
+// The HTTP client is created from the HTTP settings (timeout, proxy, TLS) of cfg;
+// a nil cfg uses the defaults.
+func NewProviderWithConfig(esmsConfig *ESMSConfig, cfg config.ConfigProvider) (model.Provider, error) {
 	// Create HTTP client
-	httpClient := client.NewClient(cfg)
+	httpClient, err := client.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	return NewProviderWithClient(esmsConfig, httpClient)
+}
+
+// NewProviderWithClient creates a eSMS provider from a typed configuration that sends
+// its requests through httpClient. The client can be
+// shared with other providers, as credentials are sent with each request.
+func NewProviderWithClient(esmsConfig *ESMSConfig, httpClient *client.Client) (model.Provider, error) {
+	if esmsConfig == nil {
+		return nil, errors.New("esms configuration is required")
+	}
+	if httpClient == nil {
+		return nil, errors.New("HTTP client is required")
+	}
+
+	// Copy the configuration so later changes by the caller do not affect the provider
+	settings := *esmsConfig
+	settings.setDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &Provider{
 		client: httpClient,
-		config: esmsConfig,
+		config: &settings,
 	}, nil
 }
 
@@ -88,15 +139,8 @@ func (p *Provider) Name() string {
 
 // SendSMS sends an SMS message using eSMS
 func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model.SendSMSResponse, error) {
-	// Get the message body from template
-	template := req.Template
-	if template == "" {
-		// If no template provided, use the Message.Body field directly or an empty string
-		template = "{message}"
-	}
-
-	// Render the message template with provided data
-	messageBody := req.Message.Render(template, req.Data)
+	// Get the message body (rendered by the module, or from the request template)
+	messageBody := req.Content()
 	if messageBody == "" {
 		return model.SendSMSResponse{}, fmt.Errorf("empty message body after rendering template")
 	}
@@ -119,7 +163,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 	params := map[string]string{
 		"ApiKey":    p.config.APIKey,
 		"SecretKey": p.config.Secret,
-		"Phone":     req.Message.To,
+		"Phone":     apiPhoneNumber(req.Message.To),
 		"Content":   messageBody,
 		"SmsType":   strconv.Itoa(p.config.SMSType),
 	}
@@ -140,6 +184,11 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 		if isUnicode, ok := req.Options["is_unicode"].(int); ok {
 			params["IsUnicode"] = strconv.Itoa(isUnicode)
 		}
+
+		// URL that eSMS posts delivery reports to
+		if callbackURL, ok := req.Options["callback_url"].(string); ok {
+			params["CallbackUrl"] = callbackURL
+		}
 	}
 
 	// Make the API request to eSMS
@@ -150,7 +199,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// Handle error responses
 	if resp.StatusCode() >= 400 {
-		return model.SendSMSResponse{}, fmt.Errorf("eSMS API error: %s", resp.String())
+		return model.SendSMSResponse{}, newESMSHTTPError(resp.String(), resp.StatusCode(), client.RetryAfter(resp))
 	}
 
 	// Parse the response
@@ -161,7 +210,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// Check for eSMS error codes
 	if esmsResp.CodeResult != "100" {
-		return model.SendSMSResponse{}, fmt.Errorf("eSMS error: %s - %s", esmsResp.CodeResult, esmsResp.ErrorMessage)
+		return model.SendSMSResponse{}, newESMSError(esmsResp.CodeResult, esmsResp.ErrorMessage, resp.StatusCode())
 	}
 
 	// Map eSMS status to our status
@@ -183,15 +232,8 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 // SendVoiceCall initiates a voice call using eSMS's OTP voice service
 func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest) (model.SendVoiceResponse, error) {
-	// Get the message body from template
-	template := req.Template
-	if template == "" {
-		// If no template provided, use default
-		template = "{message}"
-	}
-
-	// Render the message template with provided data
-	messageText := req.Message.Render(template, req.Data)
+	// Get the voice script (rendered by the module, or from the request template)
+	messageText := req.Content()
 	if messageText == "" {
 		return model.SendVoiceResponse{}, fmt.Errorf("empty message text after rendering template")
 	}
@@ -215,7 +257,7 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 	params := map[string]string{
 		"ApiKey":    p.config.APIKey,
 		"SecretKey": p.config.Secret,
-		"Phone":     req.Message.To,
+		"Phone":     apiPhoneNumber(req.Message.To),
 		"Code":      otp,
 	}
 
@@ -240,7 +282,7 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 
 	// Handle error responses
 	if resp.StatusCode() >= 400 {
-		return model.SendVoiceResponse{}, fmt.Errorf("eSMS voice API error: %s", resp.String())
+		return model.SendVoiceResponse{}, newESMSHTTPError(resp.String(), resp.StatusCode(), client.RetryAfter(resp))
 	}
 
 	// Parse the response
@@ -251,7 +293,7 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 
 	// Check for eSMS error codes
 	if esmsResp.CodeResult != "100" {
-		return model.SendVoiceResponse{}, fmt.Errorf("eSMS voice error: %s - %s", esmsResp.CodeResult, esmsResp.ErrorMessage)
+		return model.SendVoiceResponse{}, newESMSError(esmsResp.CodeResult, esmsResp.ErrorMessage, resp.StatusCode())
 	}
 
 	// Map eSMS status to our status
@@ -275,6 +317,78 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 	}, nil
 }
 
+// GetMessageStatus looks up the send status of a message by its SMSID
+func (p *Provider) GetMessageStatus(ctx context.Context, messageID string) (model.MessageStatusResponse, error) {
+	endpoint := p.config.BaseURL + ESMSSendStatusEndpoint
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"ApiKey":    p.config.APIKey,
+			"SecretKey": p.config.Secret,
+			"SMSID":     messageID,
+		}).
+		Get(endpoint)
+	if err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("eSMS API request failed: %w", err)
+	}
+
+	// Handle error responses
+	if resp.StatusCode() >= 400 {
+		return model.MessageStatusResponse{}, newESMSHTTPError(resp.String(), resp.StatusCode(), client.RetryAfter(resp))
+	}
+
+	// Parse the response
+	var esmsResp esmsStatusResponse
+	if err := json.Unmarshal(resp.Body(), &esmsResp); err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("failed to parse eSMS response: %w", err)
+	}
+
+	// Check for eSMS error codes
+	if esmsResp.CodeResult != "100" {
+		return model.MessageStatusResponse{}, newESMSError(esmsResp.CodeResult, esmsResp.ErrorMessage, resp.StatusCode())
+	}
+
+	if esmsResp.SMSID != "" {
+		messageID = esmsResp.SMSID
+	}
+
+	return model.MessageStatusResponse{
+		MessageID: messageID,
+		Status:    mapESMSSendStatus(esmsResp.SendStatus, esmsResp.SendSuccess, esmsResp.SendFailed),
+		RawStatus: strconv.Itoa(esmsResp.SendStatus),
+		Provider:  ProviderName,
+		ProviderResponse: map[string]interface{}{
+			"code_result":  esmsResp.CodeResult,
+			"sms_id":       messageID,
+			"send_status":  esmsResp.SendStatus,
+			"send_success": esmsResp.SendSuccess,
+			"send_failed":  esmsResp.SendFailed,
+		},
+	}, nil
+}
+
+// mapESMSSendStatus maps an eSMS SendStatus to our status. Once sending has completed (5),
+// the success and failure counts tell whether the message reached the recipient.
+func mapESMSSendStatus(sendStatus, sendSuccess, sendFailed int) model.MessageStatus {
+	switch sendStatus {
+	case 1, 2, 3:
+		return model.StatusPending // Waiting for approval, waiting to send, sending
+	case 4, 6:
+		return model.StatusFailed // Rejected, deleted
+	case 5:
+		switch {
+		case sendSuccess > 0:
+			return model.StatusDelivered
+		case sendFailed > 0:
+			return model.StatusFailed
+		default:
+			return model.StatusSent
+		}
+	default:
+		return model.StatusUnknown
+	}
+}
+
 // mapESMSStatusCode maps eSMS status codes to our status
 func mapESMSStatusCode(statusCode string) model.MessageStatus {
 	switch statusCode {
@@ -293,6 +407,54 @@ func mapESMSStatusCode(statusCode string) model.MessageStatus {
 	}
 }
 
+// newESMSError builds the error returned for an eSMS error response
+func newESMSError(codeResult, message string, httpStatus int) *model.ProviderError {
+	return &model.ProviderError{
+		Provider:   ProviderName,
+		Code:       codeResult,
+		HTTPStatus: httpStatus,
+		Category:   mapESMSErrorCategory(codeResult, httpStatus),
+		Message:    message,
+	}
+}
+
+// newESMSHTTPError builds the error returned for an eSMS HTTP error response.
+// retryAfter is the delay requested by the response's Retry-After header, if any.
+func newESMSHTTPError(body string, httpStatus int, retryAfter time.Duration) *model.ProviderError {
+	providerErr := newESMSError("", body, httpStatus)
+	providerErr.RetryAfter = retryAfter
+	return providerErr
+}
+
+// mapESMSErrorCategory maps eSMS CodeResult values to error categories, following the error
+// code table ("Bảng mã lỗi") of the eSMS API documentation at developers.esms.vn. Codes whose
+// meaning is not documented there are left unknown. eSMS has no code for unsubscribed
+// recipients, so none maps to ErrorCategoryOptedOut.
+func mapESMSErrorCategory(codeResult string, httpStatus int) model.ErrorCategory {
+	switch codeResult {
+	case "99":
+		return model.ErrorCategoryTransient // Unknown error, try again later
+	case "101", "102":
+		return model.ErrorCategoryAuth // Wrong ApiKey / SecretKey, account locked
+	case "103":
+		return model.ErrorCategoryInsufficientBalance
+	case "104", "118", "119":
+		// Unregistered brandname, invalid SMS type, too few recipients for an advertising brandname
+		return model.ErrorCategoryInvalidRequest
+	}
+
+	if httpStatus >= 400 {
+		return model.CategoryForHTTPStatus(httpStatus)
+	}
+	return model.ErrorCategoryUnknown
+}
+
+// apiPhoneNumber returns a recipient in the form the eSMS API expects: the country code
+// and number without the leading "+" (e.g. "84912345678")
+func apiPhoneNumber(to string) string {
+	return strings.TrimPrefix(to, "+")
+}
+
 // extractOTPFromMessage attempts to extract an OTP code from a message
 // It looks for sequences of digits (typically 4-8 digits long for OTPs)
 func extractOTPFromMessage(message string) string {
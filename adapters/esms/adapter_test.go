@@ -3,12 +3,14 @@ package esms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
@@ -115,7 +117,7 @@ func TestSendSMS(t *testing.T) {
 			// Check form values
 			assert.Equal(t, "test_api_key", r.FormValue("ApiKey"))
 			assert.Equal(t, "test_secret", r.FormValue("SecretKey"))
-			assert.Equal(t, "+84123456789", r.FormValue("Phone"))
+			assert.Equal(t, "84123456789", r.FormValue("Phone"))
 			assert.Equal(t, "TestBrand", r.FormValue("Brandname"))
 			assert.Equal(t, "Hello from MyApp: This is a test message", r.FormValue("Content"))
 			assert.Equal(t, "2", r.FormValue("SmsType"))
@@ -150,9 +152,7 @@ func TestSendSMS(t *testing.T) {
 	}
 
 	// Create a test client
-	provider.client = client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendSMSRequest{
@@ -194,7 +194,7 @@ func TestSendVoiceCall(t *testing.T) {
 			// Check form values
 			assert.Equal(t, "test_api_key", r.FormValue("ApiKey"))
 			assert.Equal(t, "test_secret", r.FormValue("SecretKey"))
-			assert.Equal(t, "+84123456789", r.FormValue("Phone"))
+			assert.Equal(t, "84123456789", r.FormValue("Phone"))
 			assert.Equal(t, "123456", r.FormValue("Code"))
 
 			// Send response
@@ -226,9 +226,7 @@ func TestSendVoiceCall(t *testing.T) {
 	}
 
 	// Create a test client
-	provider.client = client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendVoiceRequest{
@@ -277,3 +275,186 @@ func TestExtractOTPFromMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestSendSMSError(t *testing.T) {
+	// eSMS reports errors in CodeResult with HTTP 200
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(esmsSMSResponse{
+			CodeResult:   "500",
+			ErrorMessage: "Amount 500 is invalid",
+		})
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config: &ESMSConfig{
+			APIKey:  "test_api_key",
+			Secret:  "test_secret",
+			SMSType: 2,
+			BaseURL: server.URL,
+		},
+		client: newTestClient(t),
+	}
+
+	_, err := provider.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{From: "TestBrand", To: "+84912345678"},
+		Body:    "Test message",
+	})
+
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, "500", providerErr.Code)
+	assert.Equal(t, http.StatusOK, providerErr.HTTPStatus)
+	assert.Equal(t, model.ErrorCategoryUnknown, providerErr.Category)
+	assert.False(t, providerErr.Retriable())
+
+	// Known codes are categorized
+	assert.Equal(t, model.ErrorCategoryTransient, newESMSError("99", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryAuth, newESMSError("101", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryInsufficientBalance, newESMSError("103", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryInvalidRequest, newESMSError("104", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryInvalidRequest, newESMSError("118", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryInvalidRequest, newESMSError("119", "", http.StatusOK).Category)
+
+	// Undocumented codes are not guessed
+	assert.Equal(t, model.ErrorCategoryUnknown, newESMSError("106", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryUnknown, newESMSError("120", "", http.StatusOK).Category)
+	assert.Equal(t, model.ErrorCategoryTransient, newESMSError("", "", http.StatusServiceUnavailable).Category)
+}
+
+func TestGetMessageStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/sms/status", r.URL.Path)
+		assert.Equal(t, "test_api_key", r.URL.Query().Get("ApiKey"))
+		assert.Equal(t, "test_secret", r.URL.Query().Get("SecretKey"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("SMSID") {
+		case "sms-delivered":
+			w.Write([]byte(`{"CodeResult": "100", "SMSID": "sms-delivered", "SendStatus": 5, "SendSuccess": 1, "SendFailed": 0}`))
+		case "sms-pending":
+			w.Write([]byte(`{"CodeResult": "100", "SMSID": "sms-pending", "SendStatus": 2}`))
+		default:
+			w.Write([]byte(`{"CodeResult": "99", "ErrorMessage": "SMSID not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config: &ESMSConfig{
+			APIKey:  "test_api_key",
+			Secret:  "test_secret",
+			SMSType: 4,
+			BaseURL: server.URL + "/api",
+		},
+		client: newTestClient(t),
+	}
+
+	status, err := provider.GetMessageStatus(context.Background(), "sms-delivered")
+	assert.NoError(t, err)
+	assert.Equal(t, "sms-delivered", status.MessageID)
+	assert.Equal(t, model.StatusDelivered, status.Status)
+	assert.Equal(t, "5", status.RawStatus)
+	assert.Equal(t, ProviderName, status.Provider)
+
+	status, err = provider.GetMessageStatus(context.Background(), "sms-pending")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusPending, status.Status)
+
+	_, err = provider.GetMessageStatus(context.Background(), "missing")
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, "99", providerErr.Code)
+
+	// Completed sends report whether the recipient was reached
+	assert.Equal(t, model.StatusFailed, mapESMSSendStatus(5, 0, 1))
+	assert.Equal(t, model.StatusSent, mapESMSSendStatus(5, 0, 0))
+	assert.Equal(t, model.StatusFailed, mapESMSSendStatus(4, 0, 0))
+	assert.Equal(t, model.StatusUnknown, mapESMSSendStatus(0, 0, 0))
+}
+
+func TestNewProviderWithClient(t *testing.T) {
+	httpClient := newTestClient(t)
+
+	// Missing optional settings are filled in with defaults
+	p, err := NewProviderWithClient(&ESMSConfig{APIKey: "test_api_key", Secret: "test_secret", Brandname: "TestBrand"}, httpClient)
+	assert.NoError(t, err)
+
+	provider := p.(*Provider)
+	assert.Same(t, httpClient, provider.client)
+	assert.Equal(t, DefaultBaseURL, provider.config.BaseURL)
+	assert.Equal(t, DefaultSMSType, provider.config.SMSType)
+
+	// The typed configuration is validated
+	_, err = NewProviderWithClient(&ESMSConfig{APIKey: "test_api_key", Secret: "test_secret"}, httpClient)
+	assert.Error(t, err)
+
+	_, err = NewProviderWithClient(nil, httpClient)
+	assert.Error(t, err)
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		HTTPTimeout: 10 * time.Second,
+		Providers: map[string]interface{}{
+			"esms": map[string]interface{}{
+				"api_key":  "test_api_key",
+				"secret":   "test_secret",
+				"sms_type": 4,
+			},
+		},
+	}
+
+	p, err := NewProviderFromConfig(cfg, nil)
+	assert.NoError(t, err)
+	provider := p.(*Provider)
+	assert.Equal(t, "test_api_key", provider.config.APIKey)
+	assert.Equal(t, 4, provider.config.SMSType)
+	assert.Equal(t, DefaultBaseURL, provider.config.BaseURL)
+
+	// A shared client is used as is
+	httpClient := newTestClient(t)
+	p, err = NewProviderFromConfig(cfg, httpClient)
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, p.(*Provider).client)
+
+	// The provider section must be present
+	_, err = NewProviderFromConfig(&config.Config{}, nil)
+	assert.Error(t, err)
+}
+
+func TestProviderFactory(t *testing.T) {
+	assert.Contains(t, sms.ProviderFactories(), ProviderName)
+
+	module, err := sms.NewModuleFromConfig(&config.Config{
+		DefaultProvider: ProviderName,
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		Providers: map[string]interface{}{
+			ProviderName: map[string]interface{}{
+				"api_key":   "test_api_key",
+				"secret":    "test_secret",
+				"brandname": "TestBrand",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	provider, err := module.GetActiveProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderName, provider.Name())
+}
+
+// newTestClient creates the HTTP client used to reach the test servers
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	httpClient, err := client.NewClient(&config.Config{HTTPTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create HTTP client: %v", err)
+	}
+	return httpClient
+}
@@ -4,9 +4,18 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/go-fork/sms/config"
 	"github.com/spf13/viper"
 )
 
+const (
+	// DefaultBaseURL is the eSMS API base URL used when none is configured
+	DefaultBaseURL = "http://rest.esms.vn/api"
+
+	// DefaultSMSType is the SMS type used when none is configured (branded messages)
+	DefaultSMSType = 2
+)
+
 // ESMSConfig holds the configuration for the eSMS provider
 type ESMSConfig struct {
 	// APIKey is the eSMS API key
@@ -38,9 +47,34 @@ func LoadConfig(v *viper.Viper) (*ESMSConfig, error) {
 		return nil, errors.New("unable to parse esms configuration")
 	}
 
+	return decodeConfig(esmsConfig)
+}
+
+// ConfigFromProvider reads the eSMS configuration from the providers.esms
+// section of an already parsed module configuration
+func ConfigFromProvider(cfg config.ConfigProvider) (*ESMSConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("configuration is required")
+	}
+
+	settings, err := cfg.GetProviderConfig(ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("esms configuration not found: %w", err)
+	}
+
+	esmsConfig := viper.New()
+	if err := esmsConfig.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("unable to parse esms configuration: %w", err)
+	}
+
+	return decodeConfig(esmsConfig)
+}
+
+// decodeConfig unmarshals and validates the providers.esms section
+func decodeConfig(esmsConfig *viper.Viper) (*ESMSConfig, error) {
 	// Set defaults
-	esmsConfig.SetDefault("sms_type", 2) // Default to brandname messages
-	esmsConfig.SetDefault("base_url", "http://rest.esms.vn/api")
+	esmsConfig.SetDefault("sms_type", DefaultSMSType) // Default to brandname messages
+	esmsConfig.SetDefault("base_url", DefaultBaseURL)
 
 	// Unmarshal config into struct
 	config := &ESMSConfig{}
@@ -56,6 +90,16 @@ func LoadConfig(v *viper.Viper) (*ESMSConfig, error) {
 	return config, nil
 }
 
+// setDefaults fills in the optional settings left empty
+func (c *ESMSConfig) setDefaults() {
+	if c.SMSType == 0 {
+		c.SMSType = DefaultSMSType
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = DefaultBaseURL
+	}
+}
+
 // Validate validates the eSMS configuration
 func (c *ESMSConfig) Validate() error {
 	// Check required fields
@@ -0,0 +1,147 @@
+package esms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+)
+
+// esmsDeliveryReport is a delivery report eSMS posts to the callback URL of a message
+type esmsDeliveryReport struct {
+	SMSID       string `json:"SMSID"`
+	Phone       string `json:"Phone"`
+	SendStatus  int    `json:"SendStatus"`
+	SendSuccess int    `json:"SendSuccess"`
+	SendFailed  int    `json:"SendFailed"`
+	ErrorCode   string `json:"ErrorCode"`
+}
+
+// ParseDeliveryReport parses the JSON delivery reports eSMS posts to a callback URL.
+// The body is a single report or an array of reports, with the same SendStatus values
+// as the send status endpoint. It can be used as a webhook.DeliveryParserFunc.
+func ParseDeliveryReport(r *http.Request) ([]model.DeliveryEvent, error) {
+	// Accept a single report as an array of one
+	body, err := readJSONArray(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []esmsDeliveryReport
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	var payloads []map[string]interface{}
+	if err := json.Unmarshal(body, &payloads); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	receivedAt := time.Now()
+	events := make([]model.DeliveryEvent, 0, len(reports))
+	for i, report := range reports {
+		if report.SMSID == "" {
+			return nil, fmt.Errorf("%w: SMSID is missing", webhook.ErrInvalidPayload)
+		}
+
+		events = append(events, model.DeliveryEvent{
+			Provider:  ProviderName,
+			MessageID: report.SMSID,
+			Status:    mapESMSSendStatus(report.SendStatus, report.SendSuccess, report.SendFailed),
+			RawStatus: strconv.Itoa(report.SendStatus),
+			ErrorCode: report.ErrorCode,
+			Recipient: report.Phone,
+			Timestamp: receivedAt,
+			Payload:   payloads[i],
+		})
+	}
+
+	return events, nil
+}
+
+// esmsInboundMessage is a message eSMS forwards from a registered 8xx number or short code
+type esmsInboundMessage struct {
+	RequestID string `json:"RequestID"`
+	Phone     string `json:"Phone"`
+	Content   string `json:"Content"`
+	ShortCode string `json:"ShortCode"`
+}
+
+// ParseInboundMessage parses the messages eSMS forwards to the receive URL of a two-way
+// 8xx number or short code. eSMS calls the URL with Phone, Content, ShortCode and RequestID,
+// as query parameters or form fields, or as a JSON object or array.
+// It can be used as a webhook.InboundParserFunc.
+func ParseInboundMessage(r *http.Request) ([]model.InboundMessage, error) {
+	var messages []esmsInboundMessage
+	var payloads []map[string]interface{}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method == http.MethodGet || mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+		}
+		messages = []esmsInboundMessage{{
+			RequestID: r.Form.Get("RequestID"),
+			Phone:     r.Form.Get("Phone"),
+			Content:   r.Form.Get("Content"),
+			ShortCode: r.Form.Get("ShortCode"),
+		}}
+		payload := make(map[string]interface{}, len(r.Form))
+		for key := range r.Form {
+			payload[key] = r.Form.Get(key)
+		}
+		payloads = []map[string]interface{}{payload}
+	} else {
+		body, err := readJSONArray(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &messages); err != nil {
+			return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+		}
+		if err := json.Unmarshal(body, &payloads); err != nil {
+			return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+		}
+	}
+
+	receivedAt := time.Now()
+	inbound := make([]model.InboundMessage, 0, len(messages))
+	for i, msg := range messages {
+		if msg.Phone == "" {
+			return nil, fmt.Errorf("%w: Phone is missing", webhook.ErrInvalidPayload)
+		}
+
+		inbound = append(inbound, model.InboundMessage{
+			Provider:   ProviderName,
+			MessageID:  msg.RequestID,
+			From:       msg.Phone,
+			To:         msg.ShortCode,
+			Body:       msg.Content,
+			ReceivedAt: receivedAt,
+			Payload:    payloads[i],
+		})
+	}
+
+	return inbound, nil
+}
+
+// readJSONArray reads a JSON request body, wrapping a single object in an array
+func readJSONArray(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		body = append(append([]byte{'['}, body...), ']')
+	}
+	return body, nil
+}
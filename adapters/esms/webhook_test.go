@@ -0,0 +1,111 @@
+package esms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCallbackRequest builds a JSON callback request like the ones eSMS sends
+func newCallbackRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/esms/status", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestParseDeliveryReport(t *testing.T) {
+	events, err := ParseDeliveryReport(newCallbackRequest(
+		`{"SMSID": "sms-1", "Phone": "0912345678", "SendStatus": 5, "SendSuccess": 1, "SendFailed": 0}`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, ProviderName, events[0].Provider)
+	assert.Equal(t, "sms-1", events[0].MessageID)
+	assert.Equal(t, model.StatusDelivered, events[0].Status)
+	assert.Equal(t, "5", events[0].RawStatus)
+	assert.Equal(t, "0912345678", events[0].Recipient)
+	assert.Equal(t, "sms-1", events[0].Payload["SMSID"])
+	assert.False(t, events[0].Timestamp.IsZero())
+
+	// Several reports can arrive at once
+	events, err = ParseDeliveryReport(newCallbackRequest(`[
+		{"SMSID": "sms-2", "SendStatus": 4, "ErrorCode": "118"},
+		{"SMSID": "sms-3", "SendStatus": 2}
+	]`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, model.StatusFailed, events[0].Status)
+	assert.Equal(t, "118", events[0].ErrorCode)
+	assert.Equal(t, model.StatusPending, events[1].Status)
+
+	_, err = ParseDeliveryReport(newCallbackRequest(`{"SendStatus": 5}`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+	_, err = ParseDeliveryReport(newCallbackRequest(`not json`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
+
+func TestSendSMSCallbackURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "https://example.com/webhooks/esms", r.PostForm.Get("CallbackUrl"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(esmsSMSResponse{CodeResult: "100", SMSID: "sms-1"})
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config: &ESMSConfig{APIKey: "test_api_key", Secret: "test_secret", SMSType: 4, BaseURL: server.URL},
+		client: newTestClient(t),
+	}
+
+	_, err := provider.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{To: "+84912345678"},
+		Body:    "Test message",
+		Options: map[string]interface{}{"callback_url": "https://example.com/webhooks/esms"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestParseInboundMessage(t *testing.T) {
+	// Query parameters
+	req := httptest.NewRequest(http.MethodGet,
+		"/webhooks/esms/inbound?Phone=0912345678&Content=TU+CHOI&ShortCode=8079&RequestID=mo-1", nil)
+	messages, err := ParseInboundMessage(req)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, ProviderName, messages[0].Provider)
+	assert.Equal(t, "mo-1", messages[0].MessageID)
+	assert.Equal(t, "0912345678", messages[0].From)
+	assert.Equal(t, "8079", messages[0].To)
+	assert.Equal(t, "TU CHOI", messages[0].Body)
+	assert.Equal(t, "TU", messages[0].Keyword())
+	assert.Equal(t, "8079", messages[0].Payload["ShortCode"])
+
+	// Form fields
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/esms/inbound", strings.NewReader("Phone=0912345678&Content=YES"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	messages, err = ParseInboundMessage(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "YES", messages[0].Body)
+
+	// JSON array
+	messages, err = ParseInboundMessage(newCallbackRequest(
+		`[{"Phone": "0912345678", "Content": "STOP", "ShortCode": "8079"}, {"Phone": "0987654321", "Content": "yes"}]`))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "STOP", messages[0].Body)
+	assert.Equal(t, "0987654321", messages[1].From)
+
+	// Messages without a sender are rejected
+	_, err = ParseInboundMessage(newCallbackRequest(`{"Content": "STOP"}`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+	_, err = ParseInboundMessage(newCallbackRequest(`not json`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
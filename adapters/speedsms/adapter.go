@@ -3,15 +3,18 @@ package speedsms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
-	"github.com/spf13/viper"
 )
 
 const (
@@ -23,8 +26,16 @@ const (
 
 	// SpeedSMSCheckBalanceEndpoint is the endpoint for checking account balance
 	SpeedSMSCheckBalanceEndpoint = "/user/balance"
+
+	// SpeedSMSStatusEndpoint is the endpoint for looking up the delivery status of a transaction
+	SpeedSMSStatusEndpoint = "/sms/status/%s"
 )
 
+func init() {
+	// Make the provider available to sms.NewModuleFromConfig
+	sms.MustRegisterProviderFactory(ProviderName, NewProviderFromConfig)
+}
+
 // SpeedSMS API request/response structures
 type speedSMSSendRequest struct {
 	To      []string `json:"to"`
@@ -38,6 +49,19 @@ type speedSMSResponse struct {
 	Code    int      `json:"code"`
 	Message string   `json:"message"`
 	Data    []string `json:"data,omitempty"`
+	TranID  int64    `json:"tranId,omitempty"`
+}
+
+type speedSMSStatusResponse struct {
+	Status  string                   `json:"status"`
+	Code    int                      `json:"code"`
+	Message string                   `json:"message"`
+	Data    []speedSMSReceiverStatus `json:"data,omitempty"`
+}
+
+type speedSMSReceiverStatus struct {
+	Phone  string `json:"phone"`
+	Status int    `json:"status"`
 }
 
 // Provider implements the model.Provider interface for SpeedSMS
@@ -57,29 +81,58 @@ func NewProvider(configFile string) (model.Provider, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Create a new Viper instance
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+	return NewProviderFromConfig(cfg, nil)
+}
 
+// NewProviderFromConfig creates a SpeedSMS provider from the providers.speedsms section of an
+// already parsed configuration. If httpClient is nil, a client is created from cfg.
+func NewProviderFromConfig(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
 	// Load SpeedSMS-specific configuration
-	speedConfig, err := LoadConfig(v)
+	speedConfig, err := ConfigFromProvider(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load SpeedSMS configuration: %w", err)
 	}
 
+	if httpClient == nil {
+		return NewProviderWithConfig(speedConfig, cfg)
+	}
+	return NewProviderWithClient(speedConfig, httpClient)
+}
+
+// NewProviderWithConfig creates a SpeedSMS provider from a typed configuration.
+// The HTTP client is created from the HTTP settings (timeout, proxy, TLS) of cfg;
+// a nil cfg uses the defaults.
+func NewProviderWithConfig(speedConfig *SpeedSMSConfig, cfg config.ConfigProvider) (model.Provider, error) {
 	// Create HTTP client
-	httpClient := client.NewClient(cfg)
+	httpClient, err := client.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	return NewProviderWithClient(speedConfig, httpClient)
+}
+
+// NewProviderWithClient creates a SpeedSMS provider from a typed configuration that sends
+// its requests through httpClient. Credentials are sent with each request,
+// so the client can be shared with other providers.
+func NewProviderWithClient(speedConfig *SpeedSMSConfig, httpClient *client.Client) (model.Provider, error) {
+	if speedConfig == nil {
+		return nil, errors.New("speedsms configuration is required")
+	}
+	if httpClient == nil {
+		return nil, errors.New("HTTP client is required")
+	}
 
-	// Set authorization header with token
-	httpClient.SetHeader("Authorization", speedConfig.Token)
-	httpClient.SetHeader("Content-Type", "application/json")
+	// Copy the configuration so later changes by the caller do not affect the provider
+	settings := *speedConfig
+	settings.setDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &Provider{
 		client: httpClient,
-		config: speedConfig,
+		config: &settings,
 	}, nil
 }
 
@@ -90,15 +143,8 @@ func (p *Provider) Name() string {
 
 // SendSMS sends an SMS message using SpeedSMS
 func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model.SendSMSResponse, error) {
-	// Get the message body from template
-	template := req.Template
-	if template == "" {
-		// If no template provided, use default template from config
-		template = "{message}"
-	}
-
-	// Render the message template with provided data
-	messageBody := req.Message.Render(template, req.Data)
+	// Get the message body (rendered by the module, or from the request template)
+	messageBody := req.Content()
 	if messageBody == "" {
 		return model.SendSMSResponse{}, fmt.Errorf("empty message body after rendering template")
 	}
@@ -120,8 +166,9 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 		}
 	}
 
-	// SpeedSMS requires phone numbers as an array, but we're sending to just one
-	phoneNumbers := []string{req.Message.To}
+	// SpeedSMS requires phone numbers as an array, but we're sending to just one.
+	// Numbers are written with the country code but without the leading "+" (e.g. "84912345678")
+	phoneNumbers := []string{strings.TrimPrefix(req.Message.To, "+")}
 
 	// Build the request body
 	reqBody := speedSMSSendRequest{
@@ -138,6 +185,8 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 	// Make the API request to SpeedSMS
 	resp, err := p.client.R().
 		SetContext(ctx).
+		SetHeader("Authorization", p.config.Token).
+		SetHeader("Content-Type", "application/json").
 		SetBody(reqBody).
 		Post(endpoint)
 
@@ -147,7 +196,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// Handle error responses
 	if resp.StatusCode() >= 400 {
-		return model.SendSMSResponse{}, fmt.Errorf("SpeedSMS API error: %s", resp.String())
+		return model.SendSMSResponse{}, newSpeedSMSHTTPError(resp.String(), resp.StatusCode(), client.RetryAfter(resp))
 	}
 
 	// Parse the response
@@ -158,13 +207,15 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// Check for SpeedSMS error codes
 	if speedResp.Status != "success" {
-		return model.SendSMSResponse{}, fmt.Errorf("SpeedSMS error: %d - %s", speedResp.Code, speedResp.Message)
+		return model.SendSMSResponse{}, newSpeedSMSError(speedResp.Code, speedResp.Message, resp.StatusCode())
 	}
 
-	// Generate a message ID if SpeedSMS didn't provide one
-	// SpeedSMS returns success data in the 'data' field which contains the phone numbers
+	// Use the transaction ID as the message ID, so the status can be looked up later
+	// Generate a message ID if SpeedSMS didn't provide one; the 'data' field contains the phone numbers
 	messageID := fmt.Sprintf("sms_%d", time.Now().UnixNano())
-	if len(speedResp.Data) > 0 {
+	if speedResp.TranID != 0 {
+		messageID = strconv.FormatInt(speedResp.TranID, 10)
+	} else if len(speedResp.Data) > 0 {
 		messageID = fmt.Sprintf("sms_%s_%d", strings.Join(speedResp.Data, "_"), time.Now().Unix())
 	}
 
@@ -185,6 +236,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 			"code":    speedResp.Code,
 			"message": speedResp.Message,
 			"data":    speedResp.Data,
+			"tran_id": speedResp.TranID,
 		},
 	}, nil
 }
@@ -204,6 +256,7 @@ func (p *Provider) GetBalance(ctx context.Context) (float64, error) {
 
 	resp, err := p.client.R().
 		SetContext(ctx).
+		SetHeader("Authorization", p.config.Token).
 		Get(endpoint)
 
 	if err != nil {
@@ -231,3 +284,115 @@ func (p *Provider) GetBalance(ctx context.Context) (float64, error) {
 
 	return result.Data, nil
 }
+
+// GetMessageStatus looks up the delivery status of a message by its transaction ID.
+// Only messages whose send response carried a transaction ID can be looked up.
+func (p *Provider) GetMessageStatus(ctx context.Context, messageID string) (model.MessageStatusResponse, error) {
+	endpoint := p.config.BaseURL + fmt.Sprintf(SpeedSMSStatusEndpoint, url.PathEscape(messageID))
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetHeader("Authorization", p.config.Token).
+		Get(endpoint)
+	if err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("SpeedSMS API request failed: %w", err)
+	}
+
+	// Handle error responses
+	if resp.StatusCode() >= 400 {
+		return model.MessageStatusResponse{}, newSpeedSMSHTTPError(resp.String(), resp.StatusCode(), client.RetryAfter(resp))
+	}
+
+	// Parse the response
+	var speedResp speedSMSStatusResponse
+	if err := json.Unmarshal(resp.Body(), &speedResp); err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("failed to parse SpeedSMS response: %w", err)
+	}
+
+	// Check for SpeedSMS error codes
+	if speedResp.Status != "success" {
+		return model.MessageStatusResponse{}, newSpeedSMSError(speedResp.Code, speedResp.Message, resp.StatusCode())
+	}
+
+	result := model.MessageStatusResponse{
+		MessageID: messageID,
+		Status:    model.StatusUnknown,
+		Provider:  ProviderName,
+		ProviderResponse: map[string]interface{}{
+			"status": speedResp.Status,
+			"code":   speedResp.Code,
+			"data":   speedResp.Data,
+		},
+	}
+
+	// Messages are sent to a single recipient, so the transaction has one receiver status
+	if len(speedResp.Data) > 0 {
+		receiver := speedResp.Data[0]
+		result.RawStatus = strconv.Itoa(receiver.Status)
+		result.Status = mapSpeedSMSDeliveryStatus(receiver.Status)
+		if result.Status == model.StatusFailed {
+			result.ErrorCode = result.RawStatus
+		}
+	}
+
+	return result, nil
+}
+
+// mapSpeedSMSDeliveryStatus maps a SpeedSMS receiver status to our status.
+// 0 means delivered and 1 means waiting for the delivery report; other values are failure reasons.
+func mapSpeedSMSDeliveryStatus(status int) model.MessageStatus {
+	switch status {
+	case 0:
+		return model.StatusDelivered
+	case 1:
+		return model.StatusSent
+	default:
+		return model.StatusFailed
+	}
+}
+
+// newSpeedSMSError builds the error returned for a SpeedSMS error response
+// A code of 0 means the response had no SpeedSMS error code
+func newSpeedSMSError(code int, message string, httpStatus int) *model.ProviderError {
+	providerErr := &model.ProviderError{
+		Provider:   ProviderName,
+		HTTPStatus: httpStatus,
+		Category:   mapSpeedSMSErrorCategory(code, httpStatus),
+		Message:    message,
+	}
+	if code != 0 {
+		providerErr.Code = strconv.Itoa(code)
+	}
+	return providerErr
+}
+
+// newSpeedSMSHTTPError builds the error returned for a SpeedSMS HTTP error response.
+// retryAfter is the delay requested by the response's Retry-After header, if any.
+func newSpeedSMSHTTPError(body string, httpStatus int, retryAfter time.Duration) *model.ProviderError {
+	providerErr := newSpeedSMSError(0, body, httpStatus)
+	providerErr.RetryAfter = retryAfter
+	return providerErr
+}
+
+// mapSpeedSMSErrorCategory maps SpeedSMS error codes to error categories.
+// SpeedSMS has no code for unsubscribed recipients, so none maps to ErrorCategoryOptedOut.
+func mapSpeedSMSErrorCategory(code int, httpStatus int) model.ErrorCategory {
+	switch code {
+	case 7, 8, 9:
+		return model.ErrorCategoryAuth // IP locked, account locked, API access not allowed
+	case 101:
+		return model.ErrorCategoryInvalidRequest
+	case 105:
+		return model.ErrorCategoryInvalidRecipient
+	case 110, 113:
+		return model.ErrorCategoryContentRejected // Unsupported encoding, content too long
+	case 300:
+		return model.ErrorCategoryInsufficientBalance
+	case 500:
+		return model.ErrorCategoryTransient
+	}
+
+	if httpStatus >= 400 {
+		return model.CategoryForHTTPStatus(httpStatus)
+	}
+	return model.ErrorCategoryUnknown
+}
@@ -3,12 +3,14 @@ package speedsms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
@@ -124,7 +126,7 @@ func TestSendSMS(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Check request values
-			assert.Equal(t, []string{"+84123456789"}, reqBody.To)
+			assert.Equal(t, []string{"84123456789"}, reqBody.To)
 			assert.Equal(t, "Hello from MyApp: This is a test message", reqBody.Content)
 			assert.Equal(t, 2, reqBody.Type)
 			assert.Equal(t, "TestBrand", reqBody.Sender)
@@ -157,13 +159,8 @@ func TestSendSMS(t *testing.T) {
 		},
 	}
 
-	// Create a test client
-	provider.client = client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
-	// Set headers
-	provider.client.SetHeader("Authorization", provider.config.Token)
-	provider.client.SetHeader("Content-Type", "application/json")
+	// Create a test client; the token is added to each request by the provider
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendSMSRequest{
@@ -210,7 +207,34 @@ func TestSendSMS(t *testing.T) {
 	// Send message to error server
 	_, err = provider.SendSMS(context.Background(), req)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "SpeedSMS error")
+
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, ProviderName, providerErr.Provider)
+	assert.Equal(t, "1001", providerErr.Code)
+	assert.Equal(t, "Invalid phone number", providerErr.Message)
+}
+
+func TestErrorCategories(t *testing.T) {
+	tests := []struct {
+		code       int
+		httpStatus int
+		expected   model.ErrorCategory
+	}{
+		{code: 8, httpStatus: http.StatusOK, expected: model.ErrorCategoryAuth},
+		{code: 105, httpStatus: http.StatusOK, expected: model.ErrorCategoryInvalidRecipient},
+		{code: 113, httpStatus: http.StatusOK, expected: model.ErrorCategoryContentRejected},
+		{code: 300, httpStatus: http.StatusOK, expected: model.ErrorCategoryInsufficientBalance},
+		{code: 500, httpStatus: http.StatusOK, expected: model.ErrorCategoryTransient},
+		{code: 0, httpStatus: http.StatusBadGateway, expected: model.ErrorCategoryTransient},
+		{code: 0, httpStatus: http.StatusUnauthorized, expected: model.ErrorCategoryAuth},
+		{code: 9999, httpStatus: http.StatusOK, expected: model.ErrorCategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		err := newSpeedSMSError(tt.code, "error", tt.httpStatus)
+		assert.Equal(t, tt.expected, err.Category, "code %d, HTTP %d", tt.code, tt.httpStatus)
+	}
 }
 
 func TestSendVoiceCall(t *testing.T) {
@@ -225,9 +249,7 @@ func TestSendVoiceCall(t *testing.T) {
 	}
 
 	// Create a test client
-	provider.client = client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendVoiceRequest{
@@ -245,3 +267,153 @@ func TestSendVoiceCall(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not supported")
 }
+
+func TestGetMessageStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "test_token_with_at_least_20_characters", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/index.php/sms/status/1001":
+			w.Write([]byte(`{"status": "success", "code": 0, "data": [{"phone": "84912345678", "status": 0}]}`))
+		case "/index.php/sms/status/1002":
+			w.Write([]byte(`{"status": "success", "code": 0, "data": [{"phone": "84912345678", "status": 64}]}`))
+		default:
+			w.Write([]byte(`{"status": "error", "code": 101, "message": "Invalid transaction"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config: &SpeedSMSConfig{
+			Token:   "test_token_with_at_least_20_characters",
+			SMSType: 2,
+			BaseURL: server.URL + "/index.php",
+		},
+		client: newTestClient(t),
+	}
+
+	status, err := provider.GetMessageStatus(context.Background(), "1001")
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", status.MessageID)
+	assert.Equal(t, model.StatusDelivered, status.Status)
+	assert.Equal(t, "0", status.RawStatus)
+	assert.Empty(t, status.ErrorCode)
+
+	status, err = provider.GetMessageStatus(context.Background(), "1002")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, status.Status)
+	assert.Equal(t, "64", status.ErrorCode)
+
+	_, err = provider.GetMessageStatus(context.Background(), "sms_1")
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, model.ErrorCategoryInvalidRequest, providerErr.Category)
+}
+
+func TestSendSMSTransactionID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "success", "code": 0, "tranId": 123456}`))
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config: &SpeedSMSConfig{
+			Token:   "test_token_with_at_least_20_characters",
+			SMSType: 2,
+			BaseURL: server.URL,
+		},
+		client: newTestClient(t),
+	}
+
+	resp, err := provider.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{To: "+84912345678"},
+		Body:    "Test message",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", resp.MessageID)
+}
+
+func TestNewProviderWithClient(t *testing.T) {
+	httpClient := newTestClient(t)
+
+	// Missing optional settings are filled in with defaults
+	p, err := NewProviderWithClient(&SpeedSMSConfig{Token: "test_token_with_at_least_20_characters"}, httpClient)
+	assert.NoError(t, err)
+
+	provider := p.(*Provider)
+	assert.Same(t, httpClient, provider.client)
+	assert.Equal(t, DefaultBaseURL, provider.config.BaseURL)
+	assert.Equal(t, DefaultSMSType, provider.config.SMSType)
+
+	// The typed configuration is validated
+	_, err = NewProviderWithClient(&SpeedSMSConfig{Token: "short"}, httpClient)
+	assert.Error(t, err)
+
+	_, err = NewProviderWithClient(nil, httpClient)
+	assert.Error(t, err)
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		HTTPTimeout: 10 * time.Second,
+		Providers: map[string]interface{}{
+			"speedsms": map[string]interface{}{
+				"token":    "test_token_with_at_least_20_characters",
+				"sender":   "TestBrand",
+				"sms_type": 4,
+			},
+		},
+	}
+
+	p, err := NewProviderFromConfig(cfg, nil)
+	assert.NoError(t, err)
+	provider := p.(*Provider)
+	assert.Equal(t, "TestBrand", provider.config.Sender)
+	assert.Equal(t, 4, provider.config.SMSType)
+	assert.Equal(t, DefaultBaseURL, provider.config.BaseURL)
+
+	// A shared client is used as is
+	httpClient := newTestClient(t)
+	p, err = NewProviderFromConfig(cfg, httpClient)
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, p.(*Provider).client)
+
+	// The provider section must be present
+	_, err = NewProviderFromConfig(&config.Config{}, nil)
+	assert.Error(t, err)
+}
+
+func TestProviderFactory(t *testing.T) {
+	assert.Contains(t, sms.ProviderFactories(), ProviderName)
+
+	module, err := sms.NewModuleFromConfig(&config.Config{
+		DefaultProvider: ProviderName,
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		Providers: map[string]interface{}{
+			ProviderName: map[string]interface{}{
+				"token": "test_token_with_at_least_20_characters",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	provider, err := module.GetActiveProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderName, provider.Name())
+}
+
+// newTestClient creates the HTTP client used to reach the test servers
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	httpClient, err := client.NewClient(&config.Config{HTTPTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create HTTP client: %v", err)
+	}
+	return httpClient
+}
@@ -5,9 +5,18 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/go-fork/sms/config"
 	"github.com/spf13/viper"
 )
 
+const (
+	// DefaultBaseURL is the SpeedSMS API base URL used when none is configured
+	DefaultBaseURL = "https://api.speedsms.vn/index.php"
+
+	// DefaultSMSType is the SMS type used when none is configured (advertising messages)
+	DefaultSMSType = 2
+)
+
 // SpeedSMSConfig holds the configuration for the SpeedSMS provider
 type SpeedSMSConfig struct {
 	// Token is the SpeedSMS access token
@@ -36,9 +45,34 @@ func LoadConfig(v *viper.Viper) (*SpeedSMSConfig, error) {
 		return nil, errors.New("unable to parse speedsms configuration")
 	}
 
+	return decodeConfig(speedConfig)
+}
+
+// ConfigFromProvider reads the SpeedSMS configuration from the providers.speedsms
+// section of an already parsed module configuration
+func ConfigFromProvider(cfg config.ConfigProvider) (*SpeedSMSConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("configuration is required")
+	}
+
+	settings, err := cfg.GetProviderConfig(ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("speedsms configuration not found: %w", err)
+	}
+
+	speedConfig := viper.New()
+	if err := speedConfig.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("unable to parse speedsms configuration: %w", err)
+	}
+
+	return decodeConfig(speedConfig)
+}
+
+// decodeConfig unmarshals and validates the providers.speedsms section
+func decodeConfig(speedConfig *viper.Viper) (*SpeedSMSConfig, error) {
 	// Set defaults
-	speedConfig.SetDefault("base_url", "https://api.speedsms.vn/index.php")
-	speedConfig.SetDefault("sms_type", 2) // Default to advertising messages
+	speedConfig.SetDefault("base_url", DefaultBaseURL)
+	speedConfig.SetDefault("sms_type", DefaultSMSType) // Default to advertising messages
 
 	// Unmarshal config into struct
 	config := &SpeedSMSConfig{}
@@ -54,6 +88,16 @@ func LoadConfig(v *viper.Viper) (*SpeedSMSConfig, error) {
 	return config, nil
 }
 
+// setDefaults fills in the optional settings left empty
+func (c *SpeedSMSConfig) setDefaults() {
+	if c.BaseURL == "" {
+		c.BaseURL = DefaultBaseURL
+	}
+	if c.SMSType == 0 {
+		c.SMSType = DefaultSMSType
+	}
+}
+
 // Validate validates the SpeedSMS configuration
 func (c *SpeedSMSConfig) Validate() error {
 	// Check required fields
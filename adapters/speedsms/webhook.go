@@ -0,0 +1,163 @@
+package speedsms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+)
+
+const (
+	// speedSMSCallbackReport is the callback type of delivery reports
+	speedSMSCallbackReport = "report"
+
+	// speedSMSCallbackSMS is the callback type of inbound messages
+	speedSMSCallbackSMS = "sms"
+)
+
+// speedSMSCallback is a callback SpeedSMS posts to the webhook URL of the account
+type speedSMSCallback struct {
+	Type   string `json:"type"`
+	TranID int64  `json:"tranId"`
+	Phone  string `json:"phone"`
+	Status int    `json:"status"`
+
+	// Inbound messages
+	Content  string `json:"content"`
+	Receiver string `json:"receiver"`
+}
+
+// ParseDeliveryReport parses a delivery report SpeedSMS posts to the webhook URL of the account,
+// a JSON object with type "report". Callbacks of other types yield no events.
+// It can be used as a webhook.DeliveryParserFunc.
+func ParseDeliveryReport(r *http.Request) ([]model.DeliveryEvent, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	var callback speedSMSCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+	if callback.Type != speedSMSCallbackReport {
+		return nil, nil
+	}
+	if callback.TranID == 0 {
+		return nil, fmt.Errorf("%w: tranId is missing", webhook.ErrInvalidPayload)
+	}
+
+	payload, err := jsonPayload(body)
+	if err != nil {
+		return nil, err
+	}
+
+	event := model.DeliveryEvent{
+		Provider:  ProviderName,
+		MessageID: strconv.FormatInt(callback.TranID, 10),
+		Status:    mapSpeedSMSDeliveryStatus(callback.Status),
+		RawStatus: strconv.Itoa(callback.Status),
+		Recipient: callback.Phone,
+		Timestamp: time.Now(),
+		Payload:   payload,
+	}
+	if event.Status == model.StatusFailed {
+		event.ErrorCode = event.RawStatus
+	}
+
+	return []model.DeliveryEvent{event}, nil
+}
+
+// ParseInboundMessage parses an inbound message SpeedSMS posts to the webhook URL of the account,
+// a JSON object with type "sms". Callbacks of other types yield no messages.
+// It can be used as a webhook.InboundParserFunc.
+func ParseInboundMessage(r *http.Request) ([]model.InboundMessage, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	var callback speedSMSCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+	if callback.Type != speedSMSCallbackSMS {
+		return nil, nil
+	}
+	if callback.Phone == "" {
+		return nil, fmt.Errorf("%w: phone is missing", webhook.ErrInvalidPayload)
+	}
+
+	payload, err := jsonPayload(body)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := model.InboundMessage{
+		Provider:   ProviderName,
+		From:       callback.Phone,
+		To:         callback.Receiver,
+		Body:       callback.Content,
+		ReceivedAt: time.Now(),
+		Payload:    payload,
+	}
+	if callback.TranID != 0 {
+		msg.MessageID = strconv.FormatInt(callback.TranID, 10)
+	}
+
+	return []model.InboundMessage{msg}, nil
+}
+
+// NewCallbackHandler returns a handler for the webhook URL of a SpeedSMS account, which receives
+// both delivery reports and inbound messages: "report" callbacks are passed to delivery and
+// "sms" callbacks to inbound. Either may be nil; callbacks without a handler are acknowledged
+// and dropped.
+func NewCallbackHandler(delivery, inbound http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			http.Error(w, "request body is missing", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhook.DefaultMaxBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var callback speedSMSCallback
+		if err := json.Unmarshal(body, &callback); err != nil {
+			http.Error(w, fmt.Sprintf("%v: %v", webhook.ErrInvalidPayload, err), http.StatusBadRequest)
+			return
+		}
+
+		var next http.Handler
+		switch callback.Type {
+		case speedSMSCallbackReport:
+			next = delivery
+		case speedSMSCallbackSMS:
+			next = inbound
+		}
+		if next == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// jsonPayload decodes the raw fields of a JSON callback
+func jsonPayload(body []byte) (map[string]interface{}, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+	return payload, nil
+}
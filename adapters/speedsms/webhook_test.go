@@ -0,0 +1,102 @@
+package speedsms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCallbackRequest builds a JSON callback request like the ones SpeedSMS sends
+func newCallbackRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/speedsms", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestParseDeliveryReport(t *testing.T) {
+	events, err := ParseDeliveryReport(newCallbackRequest(`{"type": "report", "tranId": 123456, "phone": "84912345678", "status": 0}`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, ProviderName, events[0].Provider)
+	assert.Equal(t, "123456", events[0].MessageID)
+	assert.Equal(t, model.StatusDelivered, events[0].Status)
+	assert.Equal(t, "0", events[0].RawStatus)
+	assert.Empty(t, events[0].ErrorCode)
+	assert.Equal(t, "84912345678", events[0].Recipient)
+	assert.Equal(t, "report", events[0].Payload["type"])
+
+	events, err = ParseDeliveryReport(newCallbackRequest(`{"type": "report", "tranId": 123457, "phone": "84912345678", "status": 64}`))
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, events[0].Status)
+	assert.Equal(t, "64", events[0].ErrorCode)
+
+	// Other callbacks, such as inbound messages, are not delivery reports
+	events, err = ParseDeliveryReport(newCallbackRequest(`{"type": "sms", "phone": "84912345678", "content": "STOP"}`))
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = ParseDeliveryReport(newCallbackRequest(`{"type": "report", "status": 0}`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+	_, err = ParseDeliveryReport(newCallbackRequest(`not json`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
+
+func TestParseInboundMessage(t *testing.T) {
+	messages, err := ParseInboundMessage(newCallbackRequest(
+		`{"type": "sms", "phone": "84912345678", "receiver": "8079", "content": "Huy dich vu"}`))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, ProviderName, messages[0].Provider)
+	assert.Equal(t, "84912345678", messages[0].From)
+	assert.Equal(t, "8079", messages[0].To)
+	assert.Equal(t, "Huy dich vu", messages[0].Body)
+	assert.Equal(t, "HUY", messages[0].Keyword())
+	assert.Empty(t, messages[0].MessageID)
+	assert.Equal(t, "sms", messages[0].Payload["type"])
+
+	// Delivery reports are not inbound messages
+	messages, err = ParseInboundMessage(newCallbackRequest(`{"type": "report", "tranId": 123456, "phone": "84912345678", "status": 0}`))
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+
+	_, err = ParseInboundMessage(newCallbackRequest(`{"type": "sms", "content": "STOP"}`))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
+
+func TestCallbackHandler(t *testing.T) {
+	events := make(chan model.DeliveryEvent, 1)
+	inbound := make(chan model.InboundMessage, 1)
+	handler := NewCallbackHandler(
+		webhook.NewDeliveryHandler(webhook.DeliveryParserFunc(ParseDeliveryReport), webhook.ToChannel(events)),
+		webhook.NewInboundHandler(webhook.InboundParserFunc(ParseInboundMessage), webhook.InboundToChannel(inbound)),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newCallbackRequest(`{"type": "report", "tranId": 123456, "phone": "84912345678", "status": 0}`))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "123456", (<-events).MessageID)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newCallbackRequest(`{"type": "sms", "phone": "84912345678", "content": "STOP"}`))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "STOP", (<-inbound).Body)
+
+	// Unknown callback types are acknowledged
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newCallbackRequest(`{"type": "balance"}`))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newCallbackRequest(`not json`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Callbacks without a handler are dropped
+	w = httptest.NewRecorder()
+	NewCallbackHandler(nil, nil).ServeHTTP(w, newCallbackRequest(`{"type": "sms", "phone": "84912345678", "content": "STOP"}`))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
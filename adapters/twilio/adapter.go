@@ -3,15 +3,17 @@ package twilio
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
-	"github.com/spf13/viper"
 )
 
 const (
@@ -27,8 +29,16 @@ const (
 
 	// TwilioCallEndpoint is the endpoint for making calls
 	TwilioCallEndpoint = "/Calls.json"
+
+	// TwilioMessageEndpoint is the endpoint for fetching a message by SID
+	TwilioMessageEndpoint = "/Messages/%s.json"
 )
 
+func init() {
+	// Make the provider available to sms.NewModuleFromConfig
+	sms.MustRegisterProviderFactory(ProviderName, NewProviderFromConfig)
+}
+
 // Twilio API response structures
 type twilioSMSResponse struct {
 	SID          string `json:"sid"`
@@ -42,6 +52,17 @@ type twilioSMSResponse struct {
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
+// twilioMessageResponse is the message resource returned by the fetch endpoint
+type twilioMessageResponse struct {
+	SID          string      `json:"sid"`
+	Status       string      `json:"status"`
+	DateUpdated  string      `json:"date_updated"`
+	ErrorCode    json.Number `json:"error_code"`
+	ErrorMessage string      `json:"error_message"`
+	Price        string      `json:"price"`
+	PriceUnit    string      `json:"price_unit"`
+}
+
 type twilioCallResponse struct {
 	SID          string `json:"sid"`
 	Status       string `json:"status"`
@@ -55,6 +76,14 @@ type twilioCallResponse struct {
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
+// twilioErrorResponse is the body Twilio returns with HTTP error statuses
+type twilioErrorResponse struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info"`
+	Status   int    `json:"status"`
+}
+
 // Provider implements the model.Provider interface for Twilio
 type Provider struct {
 	// client is the HTTP client for making API requests
@@ -75,36 +104,66 @@ func NewProvider(configFile string) (model.Provider, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Create a new Viper instance
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+	return NewProviderFromConfig(cfg, nil)
+}
 
+// NewProviderFromConfig creates a Twilio provider from the providers.twilio section of an
+// already parsed configuration. If httpClient is nil, a client is created from cfg.
+func NewProviderFromConfig(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
 	// Load Twilio-specific configuration
-	twilioConfig, err := LoadConfig(v)
+	twilioConfig, err := ConfigFromProvider(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load Twilio configuration: %w", err)
 	}
 
+	if httpClient == nil {
+		return NewProviderWithConfig(twilioConfig, cfg)
+	}
+	return NewProviderWithClient(twilioConfig, httpClient)
+}
+
+// NewProviderWithConfig creates a Twilio provider from a typed configuration.
+// The HTTP client is created from the HTTP settings (timeout, proxy, TLS) of cfg;
+// a nil cfg uses the defaults.
+func NewProviderWithConfig(twilioConfig *TwilioConfig, cfg config.ConfigProvider) (model.Provider, error) {
 	// Create HTTP client
-	httpClient := client.NewClient(cfg)
+	httpClient, err := client.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
 
-	// Set basic authentication with Twilio credentials
-	httpClient.SetBasicAuth(twilioConfig.AccountSID, twilioConfig.AuthToken)
+	return NewProviderWithClient(twilioConfig, httpClient)
+}
+
+// NewProviderWithClient creates a Twilio provider from a typed configuration that sends
+// its requests through httpClient. Credentials are set on each request, so the client
+// can be shared with other providers.
+func NewProviderWithClient(twilioConfig *TwilioConfig, httpClient *client.Client) (model.Provider, error) {
+	if twilioConfig == nil {
+		return nil, errors.New("twilio configuration is required")
+	}
+	if httpClient == nil {
+		return nil, errors.New("HTTP client is required")
+	}
+
+	// Copy the configuration so later changes by the caller do not affect the provider
+	settings := *twilioConfig
+	settings.setDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Construct base URL
 	baseURL := fmt.Sprintf(
 		TwilioBaseURLTemplate,
-		twilioConfig.Region,
-		twilioConfig.APIVersion,
-		twilioConfig.AccountSID,
+		settings.Region,
+		settings.APIVersion,
+		settings.AccountSID,
 	)
 
 	return &Provider{
 		client:  httpClient,
-		config:  twilioConfig,
+		config:  &settings,
 		baseURL: baseURL,
 	}, nil
 }
@@ -116,15 +175,8 @@ func (p *Provider) Name() string {
 
 // SendSMS sends an SMS message using Twilio
 func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model.SendSMSResponse, error) {
-	// Get the message body from template
-	template := req.Template
-	if template == "" {
-		// If no template provided, use the Message.Body field directly or an empty string
-		template = "{message}"
-	}
-
-	// Render the message template with provided data
-	messageBody := req.Message.Render(template, req.Data)
+	// Get the message body (rendered by the module, or from the request template)
+	messageBody := req.Content()
 	if messageBody == "" {
 		return model.SendSMSResponse{}, fmt.Errorf("empty message body after rendering template")
 	}
@@ -152,14 +204,18 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// Make the API request to Twilio
 	endpoint := p.baseURL + TwilioSMSEndpoint
-	resp, err := p.client.PostForm(ctx, endpoint, formData)
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetBasicAuth(p.config.AccountSID, p.config.AuthToken).
+		SetFormData(formData).
+		Post(endpoint)
 	if err != nil {
 		return model.SendSMSResponse{}, fmt.Errorf("twilio API request failed: %w", err)
 	}
 
 	// Handle error responses
 	if resp.StatusCode() >= 400 {
-		return model.SendSMSResponse{}, fmt.Errorf("twilio API error: %s", resp.String())
+		return model.SendSMSResponse{}, parseTwilioError(resp.Body(), resp.StatusCode(), client.RetryAfter(resp))
 	}
 
 	// Parse the response
@@ -170,7 +226,7 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 	// If Twilio returned an error
 	if twilioResp.ErrorCode != "" {
-		return model.SendSMSResponse{}, fmt.Errorf("twilio error: %s - %s", twilioResp.ErrorCode, twilioResp.ErrorMessage)
+		return model.SendSMSResponse{}, newTwilioError(twilioResp.ErrorCode, twilioResp.ErrorMessage, resp.StatusCode())
 	}
 
 	// Map Twilio status to our status
@@ -210,15 +266,8 @@ func (p *Provider) SendSMS(ctx context.Context, req model.SendSMSRequest) (model
 
 // SendVoiceCall initiates a voice call using Twilio
 func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest) (model.SendVoiceResponse, error) {
-	// Get the message body from template
-	template := req.Template
-	if template == "" {
-		// If no template provided, use default
-		template = "{message}"
-	}
-
-	// Render the message template with provided data
-	messageText := req.Message.Render(template, req.Data)
+	// Get the voice script (rendered by the module, or from the request template)
+	messageText := req.Content()
 	if messageText == "" {
 		return model.SendVoiceResponse{}, fmt.Errorf("empty message text after rendering template")
 	}
@@ -260,14 +309,18 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 
 	// Make the API request to Twilio
 	endpoint := p.baseURL + TwilioCallEndpoint
-	resp, err := p.client.PostForm(ctx, endpoint, formData)
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetBasicAuth(p.config.AccountSID, p.config.AuthToken).
+		SetFormData(formData).
+		Post(endpoint)
 	if err != nil {
 		return model.SendVoiceResponse{}, fmt.Errorf("twilio API request failed: %w", err)
 	}
 
 	// Handle error responses
 	if resp.StatusCode() >= 400 {
-		return model.SendVoiceResponse{}, fmt.Errorf("twilio API error: %s", resp.String())
+		return model.SendVoiceResponse{}, parseTwilioError(resp.Body(), resp.StatusCode(), client.RetryAfter(resp))
 	}
 
 	// Parse the response
@@ -278,7 +331,7 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 
 	// If Twilio returned an error
 	if twilioResp.ErrorCode != "" {
-		return model.SendVoiceResponse{}, fmt.Errorf("twilio error: %s - %s", twilioResp.ErrorCode, twilioResp.ErrorMessage)
+		return model.SendVoiceResponse{}, newTwilioError(twilioResp.ErrorCode, twilioResp.ErrorMessage, resp.StatusCode())
 	}
 
 	// Map Twilio status to our status
@@ -332,18 +385,117 @@ func (p *Provider) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest
 	}, nil
 }
 
+// GetMessageStatus fetches the current status of a message by its SID
+func (p *Provider) GetMessageStatus(ctx context.Context, messageID string) (model.MessageStatusResponse, error) {
+	endpoint := p.baseURL + fmt.Sprintf(TwilioMessageEndpoint, url.PathEscape(messageID))
+	resp, err := p.client.R().
+		SetContext(ctx).
+		SetBasicAuth(p.config.AccountSID, p.config.AuthToken).
+		Get(endpoint)
+	if err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("twilio API request failed: %w", err)
+	}
+
+	// Handle error responses
+	if resp.StatusCode() >= 400 {
+		return model.MessageStatusResponse{}, parseTwilioError(resp.Body(), resp.StatusCode(), client.RetryAfter(resp))
+	}
+
+	// Parse the response
+	var twilioResp twilioMessageResponse
+	if err := json.Unmarshal(resp.Body(), &twilioResp); err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("failed to parse Twilio response: %w", err)
+	}
+
+	// Twilio dates use the RFC 2822 format
+	var updatedAt time.Time
+	if twilioResp.DateUpdated != "" {
+		if t, err := time.Parse(time.RFC1123Z, twilioResp.DateUpdated); err == nil {
+			updatedAt = t
+		}
+	}
+
+	return model.MessageStatusResponse{
+		MessageID:    twilioResp.SID,
+		Status:       mapTwilioSMSStatus(twilioResp.Status),
+		RawStatus:    twilioResp.Status,
+		Provider:     ProviderName,
+		ErrorCode:    twilioResp.ErrorCode.String(),
+		ErrorMessage: twilioResp.ErrorMessage,
+		UpdatedAt:    updatedAt,
+		ProviderResponse: map[string]interface{}{
+			"sid":          twilioResp.SID,
+			"status":       twilioResp.Status,
+			"date_updated": twilioResp.DateUpdated,
+			"price":        twilioResp.Price,
+			"price_unit":   twilioResp.PriceUnit,
+		},
+	}, nil
+}
+
+// parseTwilioError builds the error returned for a Twilio HTTP error response.
+// retryAfter is the delay requested by the response's Retry-After header, if any.
+func parseTwilioError(body []byte, httpStatus int, retryAfter time.Duration) *model.ProviderError {
+	var providerErr *model.ProviderError
+	var errResp twilioErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Code == 0 {
+		providerErr = newTwilioError("", string(body), httpStatus)
+	} else {
+		providerErr = newTwilioError(strconv.Itoa(errResp.Code), errResp.Message, httpStatus)
+	}
+	providerErr.RetryAfter = retryAfter
+	return providerErr
+}
+
+// newTwilioError builds the error returned for a Twilio error code
+func newTwilioError(code, message string, httpStatus int) *model.ProviderError {
+	return &model.ProviderError{
+		Provider:   ProviderName,
+		Code:       code,
+		HTTPStatus: httpStatus,
+		Category:   mapTwilioErrorCategory(code, httpStatus),
+		Message:    message,
+	}
+}
+
+// mapTwilioErrorCategory maps Twilio error codes to error categories
+// See https://www.twilio.com/docs/api/errors
+func mapTwilioErrorCategory(code string, httpStatus int) model.ErrorCategory {
+	switch code {
+	case "20003", "20005":
+		return model.ErrorCategoryAuth // Authentication failed, account not active
+	case "20429", "14107":
+		return model.ErrorCategoryRateLimited
+	case "21211", "21612", "21614":
+		return model.ErrorCategoryInvalidRecipient // Invalid 'To', unroutable, not a mobile number
+	case "21610":
+		return model.ErrorCategoryOptedOut // Recipient replied STOP to the sender
+	case "21617", "30007":
+		return model.ErrorCategoryContentRejected // Body too long, carrier filtering
+	case "21212", "21606", "21602":
+		return model.ErrorCategoryInvalidRequest // Invalid 'From', missing body
+	case "20500", "20503", "30001":
+		return model.ErrorCategoryTransient
+	}
+
+	if httpStatus >= 400 {
+		return model.CategoryForHTTPStatus(httpStatus)
+	}
+	return model.ErrorCategoryUnknown
+}
+
 // mapTwilioSMSStatus maps Twilio SMS status to our status
 func mapTwilioSMSStatus(twilioStatus string) model.MessageStatus {
 	switch strings.ToLower(twilioStatus) {
-	case "queued":
+	case "queued", "accepted", "scheduled":
 		return model.StatusPending
 	case "sending":
 		return model.StatusPending
 	case "sent":
 		return model.StatusSent
-	case "delivered":
+	case "delivered", "read":
 		return model.StatusDelivered
-	case "undelivered", "failed":
+	case "undelivered", "failed", "canceled":
 		return model.StatusFailed
 	default:
 		return model.StatusUnknown
@@ -3,12 +3,14 @@ package twilio
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
@@ -153,12 +155,8 @@ func TestSendSMS(t *testing.T) {
 		baseURL: server.URL + "/2010-04-01/Accounts/AC123",
 	}
 
-	// Create a test client
-	client := client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
-	client.SetBasicAuth(provider.config.AccountSID, provider.config.AuthToken)
-	provider.client = client
+	// Create a test client; credentials are added to each request by the provider
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendSMSRequest{
@@ -244,12 +242,8 @@ func TestSendVoiceCall(t *testing.T) {
 		baseURL: server.URL + "/2010-04-01/Accounts/AC123",
 	}
 
-	// Create a test client
-	client := client.NewClient(&config.Config{
-		HTTPTimeout: 10 * time.Second,
-	})
-	client.SetBasicAuth(provider.config.AccountSID, provider.config.AuthToken)
-	provider.client = client
+	// Create a test client; credentials are added to each request by the provider
+	provider.client = newTestClient(t)
 
 	// Create request
 	req := model.SendVoiceRequest{
@@ -281,3 +275,228 @@ func TestSendVoiceCall(t *testing.T) {
 	assert.Equal(t, "USD", resp.Currency)
 	assert.Equal(t, 0, resp.Duration)
 }
+
+func TestSendSMSError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 21211, "message": "The 'To' number +1555 is not a valid phone number.", "more_info": "https://www.twilio.com/docs/errors/21211", "status": 400}`))
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config:  &TwilioConfig{AccountSID: "AC123", AuthToken: "auth123", FromNumber: "+0987654321"},
+		baseURL: server.URL,
+		client:  newTestClient(t),
+	}
+
+	_, err := provider.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{To: "+1555"},
+		Body:    "Test message",
+	})
+
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, ProviderName, providerErr.Provider)
+	assert.Equal(t, "21211", providerErr.Code)
+	assert.Equal(t, http.StatusBadRequest, providerErr.HTTPStatus)
+	assert.Equal(t, model.ErrorCategoryInvalidRecipient, providerErr.Category)
+
+	// Responses without a Twilio error body fall back to the HTTP status
+	assert.Equal(t, model.ErrorCategoryRateLimited, parseTwilioError([]byte("Too Many Requests"), http.StatusTooManyRequests, 0).Category)
+	assert.Equal(t, model.ErrorCategoryAuth, parseTwilioError([]byte(`{"code": 20003, "status": 401}`), http.StatusUnauthorized, 0).Category)
+
+	// Recipients who replied STOP are reported as opted out
+	assert.Equal(t, model.ErrorCategoryOptedOut, parseTwilioError(
+		[]byte(`{"code": 21610, "message": "Attempt to send to unsubscribed recipient", "status": 400}`), http.StatusBadRequest, 0).Category)
+}
+
+func TestSendSMSRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code": 20429, "message": "Too Many Requests", "status": 429}`))
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config:  &TwilioConfig{AccountSID: "AC123", AuthToken: "auth123", FromNumber: "+0987654321"},
+		baseURL: server.URL,
+		client:  newTestClient(t),
+	}
+
+	_, err := provider.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{To: "+14155552671"},
+		Body:    "Test message",
+	})
+
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, model.ErrorCategoryRateLimited, providerErr.Category)
+	assert.Equal(t, 3*time.Second, providerErr.RetryAfter)
+}
+
+func TestGetMessageStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		username, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", username)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/Messages/SM123.json":
+			w.Write([]byte(`{"sid": "SM123", "status": "undelivered", "error_code": 30003, "error_message": "Unreachable destination handset", "date_updated": "Tue, 01 Oct 2024 08:30:00 +0000"}`))
+		case "/Messages/SM456.json":
+			w.Write([]byte(`{"sid": "SM456", "status": "delivered", "error_code": null, "error_message": null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 20404, "message": "The requested resource was not found", "status": 404}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := &Provider{
+		config:  &TwilioConfig{AccountSID: "AC123", AuthToken: "auth123", FromNumber: "+0987654321"},
+		baseURL: server.URL,
+		client:  newTestClient(t),
+	}
+
+	status, err := provider.GetMessageStatus(context.Background(), "SM123")
+	assert.NoError(t, err)
+	assert.Equal(t, "SM123", status.MessageID)
+	assert.Equal(t, model.StatusFailed, status.Status)
+	assert.Equal(t, "undelivered", status.RawStatus)
+	assert.Equal(t, "30003", status.ErrorCode)
+	assert.Equal(t, "Unreachable destination handset", status.ErrorMessage)
+	assert.Equal(t, time.Date(2024, 10, 1, 8, 30, 0, 0, time.UTC), status.UpdatedAt.UTC())
+
+	status, err = provider.GetMessageStatus(context.Background(), "SM456")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusDelivered, status.Status)
+	assert.Empty(t, status.ErrorCode)
+
+	_, err = provider.GetMessageStatus(context.Background(), "SM789")
+	var providerErr *model.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, "20404", providerErr.Code)
+	assert.Equal(t, http.StatusNotFound, providerErr.HTTPStatus)
+}
+
+func TestNewProviderWithClient(t *testing.T) {
+	httpClient := newTestClient(t)
+
+	// Missing optional settings are filled in with defaults
+	p, err := NewProviderWithClient(&TwilioConfig{
+		AccountSID: "AC123",
+		AuthToken:  "auth123",
+		FromNumber: "+0987654321",
+	}, httpClient)
+	assert.NoError(t, err)
+
+	provider := p.(*Provider)
+	assert.Same(t, httpClient, provider.client)
+	assert.Equal(t, DefaultRegion, provider.config.Region)
+	assert.Equal(t, DefaultAPIVersion, provider.config.APIVersion)
+	assert.Equal(t, "https://api.us1.twilio.com/2010-04-01/Accounts/AC123", provider.baseURL)
+
+	// The typed configuration is validated
+	_, err = NewProviderWithClient(&TwilioConfig{AccountSID: "AC123"}, httpClient)
+	assert.Error(t, err)
+
+	_, err = NewProviderWithClient(nil, httpClient)
+	assert.Error(t, err)
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		HTTPTimeout: 10 * time.Second,
+		Providers: map[string]interface{}{
+			"twilio": map[string]interface{}{
+				"account_sid": "AC123",
+				"auth_token":  "auth123",
+				"from_number": "+0987654321",
+				"region":      "ie1",
+			},
+		},
+	}
+
+	p, err := NewProviderFromConfig(cfg, nil)
+	assert.NoError(t, err)
+	provider := p.(*Provider)
+	assert.Equal(t, "ie1", provider.config.Region)
+	assert.Equal(t, DefaultAPIVersion, provider.config.APIVersion)
+
+	// A shared client is used as is
+	httpClient := newTestClient(t)
+	p, err = NewProviderFromConfig(cfg, httpClient)
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, p.(*Provider).client)
+
+	// The provider section must be present
+	_, err = NewProviderFromConfig(&config.Config{}, nil)
+	assert.Error(t, err)
+}
+
+func TestSharedClientCredentials(t *testing.T) {
+	var usernames []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _, _ := r.BasicAuth()
+		usernames = append(usernames, username)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM1", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	// Two accounts sharing one client each send their own credentials
+	httpClient := newTestClient(t)
+	for _, sid := range []string{"AC111", "AC222"} {
+		p, err := NewProviderWithClient(&TwilioConfig{AccountSID: sid, AuthToken: "auth", FromNumber: "+0987654321"}, httpClient)
+		assert.NoError(t, err)
+		p.(*Provider).baseURL = server.URL
+
+		_, err = p.SendSMS(context.Background(), model.SendSMSRequest{
+			Message: model.Message{To: "+14155552671"},
+			Body:    "Test message",
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"AC111", "AC222"}, usernames)
+}
+
+func TestProviderFactory(t *testing.T) {
+	assert.Contains(t, sms.ProviderFactories(), ProviderName)
+
+	module, err := sms.NewModuleFromConfig(&config.Config{
+		DefaultProvider: ProviderName,
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		Providers: map[string]interface{}{
+			ProviderName: map[string]interface{}{
+				"account_sid": "AC123",
+				"auth_token":  "auth123",
+				"from_number": "+0987654321",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	provider, err := module.GetActiveProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderName, provider.Name())
+}
+
+// newTestClient creates the HTTP client used to reach the test servers
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	httpClient, err := client.NewClient(&config.Config{HTTPTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create HTTP client: %v", err)
+	}
+	return httpClient
+}
@@ -5,9 +5,18 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/go-fork/sms/config"
 	"github.com/spf13/viper"
 )
 
+const (
+	// DefaultRegion is the Twilio region used when none is configured
+	DefaultRegion = "us1"
+
+	// DefaultAPIVersion is the Twilio API version used when none is configured
+	DefaultAPIVersion = "2010-04-01"
+)
+
 // TwilioConfig holds the configuration for the Twilio provider
 type TwilioConfig struct {
 	// AccountSID is the Twilio account SID
@@ -39,9 +48,34 @@ func LoadConfig(v *viper.Viper) (*TwilioConfig, error) {
 		return nil, errors.New("unable to parse twilio configuration")
 	}
 
+	return decodeConfig(twilioConfig)
+}
+
+// ConfigFromProvider reads the Twilio configuration from the providers.twilio
+// section of an already parsed module configuration
+func ConfigFromProvider(cfg config.ConfigProvider) (*TwilioConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("configuration is required")
+	}
+
+	settings, err := cfg.GetProviderConfig(ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("twilio configuration not found: %w", err)
+	}
+
+	twilioConfig := viper.New()
+	if err := twilioConfig.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("unable to parse twilio configuration: %w", err)
+	}
+
+	return decodeConfig(twilioConfig)
+}
+
+// decodeConfig unmarshals and validates the providers.twilio section
+func decodeConfig(twilioConfig *viper.Viper) (*TwilioConfig, error) {
 	// Set defaults
-	twilioConfig.SetDefault("region", "us1")
-	twilioConfig.SetDefault("api_version", "2010-04-01")
+	twilioConfig.SetDefault("region", DefaultRegion)
+	twilioConfig.SetDefault("api_version", DefaultAPIVersion)
 
 	// Unmarshal config into struct
 	config := &TwilioConfig{}
@@ -57,6 +91,16 @@ func LoadConfig(v *viper.Viper) (*TwilioConfig, error) {
 	return config, nil
 }
 
+// setDefaults fills in the optional settings left empty
+func (c *TwilioConfig) setDefaults() {
+	if c.Region == "" {
+		c.Region = DefaultRegion
+	}
+	if c.APIVersion == "" {
+		c.APIVersion = DefaultAPIVersion
+	}
+}
+
 // Validate validates the Twilio configuration
 func (c *TwilioConfig) Validate() error {
 	// Check required fields
@@ -80,7 +124,7 @@ func (c *TwilioConfig) Validate() error {
 	// Validate the phone number format (basic check)
 	// E.164 format: +country code followed by number
 	if !strings.HasPrefix(c.FromNumber, "+") {
-		return errors.New("from_number must be in E.164 format (e.g., +1234567890)")
+		return errors.New("from_number must be in E.164 format (e.g., +15005550006)")
 	}
 
 	return nil
@@ -0,0 +1,242 @@
+package twilio
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-fork/sms/webhook"
+)
+
+// SignatureHeader is the header Twilio signs its webhook requests with
+const SignatureHeader = "X-Twilio-Signature"
+
+// ErrInvalidSignature is matched by errors.Is when a request does not carry a valid Twilio signature
+var ErrInvalidSignature = errors.New("invalid Twilio signature")
+
+// ComputeSignature returns the X-Twilio-Signature of a request to rawURL with the given POST
+// parameters: the Base64 HMAC-SHA1, keyed by the auth token, of the URL followed by each
+// parameter name and value, sorted by name
+func ComputeSignature(authToken, rawURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(rawURL)
+	for _, key := range keys {
+		values := append([]string(nil), params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSignature reports whether signature is the one Twilio computes for a request to rawURL
+// with the given POST parameters. As Twilio may sign the URL with or without the default port,
+// both forms are accepted.
+func ValidateSignature(authToken, rawURL string, params url.Values, signature string) bool {
+	for _, candidate := range urlVariants(rawURL) {
+		expected := ComputeSignature(authToken, candidate, params)
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureOption configures a SignatureValidator
+type SignatureOption func(*SignatureValidator)
+
+// WithPublicURL sets the public base URL that Twilio calls, e.g. "https://api.example.com/sms",
+// for services behind a proxy that rewrites the scheme, host or path prefix. The path and
+// query of the incoming request are appended to it.
+func WithPublicURL(publicURL string) SignatureOption {
+	return func(v *SignatureValidator) {
+		v.urlFunc = func(r *http.Request) string {
+			return strings.TrimSuffix(publicURL, "/") + r.URL.RequestURI()
+		}
+	}
+}
+
+// WithForwardedHeaders rebuilds the request URL from the X-Forwarded-Proto and X-Forwarded-Host
+// headers set by a proxy. Only use it when the proxy overwrites these headers.
+func WithForwardedHeaders() SignatureOption {
+	return func(v *SignatureValidator) {
+		v.urlFunc = func(r *http.Request) string {
+			scheme, host := requestScheme(r), r.Host
+			if proto := forwardedValue(r, "X-Forwarded-Proto"); proto != "" {
+				scheme = proto
+			}
+			if forwardedHost := forwardedValue(r, "X-Forwarded-Host"); forwardedHost != "" {
+				host = forwardedHost
+			}
+			return scheme + "://" + host + r.URL.RequestURI()
+		}
+	}
+}
+
+// WithURLFunc sets how the URL that Twilio called is rebuilt from a request
+func WithURLFunc(urlFunc func(r *http.Request) string) SignatureOption {
+	return func(v *SignatureValidator) {
+		v.urlFunc = urlFunc
+	}
+}
+
+// SignatureValidator checks the X-Twilio-Signature of incoming webhook requests
+type SignatureValidator struct {
+	// authToken is the key Twilio signs requests with
+	authToken string
+
+	// urlFunc rebuilds the URL that Twilio called
+	urlFunc func(r *http.Request) string
+}
+
+// NewSignatureValidator creates a validator for requests signed with the auth token of the account.
+// By default the URL is rebuilt from the request as received; use WithPublicURL or
+// WithForwardedHeaders behind a proxy.
+func NewSignatureValidator(authToken string, opts ...SignatureOption) *SignatureValidator {
+	v := &SignatureValidator{
+		authToken: authToken,
+		urlFunc: func(r *http.Request) string {
+			return requestScheme(r) + "://" + r.Host + r.URL.RequestURI()
+		},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// NewSignatureValidator creates a validator for requests signed with the AuthToken of the configuration
+func (c *TwilioConfig) NewSignatureValidator(opts ...SignatureOption) *SignatureValidator {
+	return NewSignatureValidator(c.AuthToken, opts...)
+}
+
+// Validate checks the signature of a request. Form-encoded bodies are parsed into r.PostForm;
+// other bodies are checked against the bodySHA256 query parameter and left readable.
+// The returned error matches ErrInvalidSignature when the signature does not match.
+func (v *SignatureValidator) Validate(r *http.Request) error {
+	signature := r.Header.Get(SignatureHeader)
+	if signature == "" {
+		return fmt.Errorf("%w: %s header is missing", ErrInvalidSignature, SignatureHeader)
+	}
+
+	rawURL := v.urlFunc(r)
+
+	var params url.Values
+	if isFormRequest(r) {
+		if err := r.ParseForm(); err != nil {
+			return fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+		}
+		params = r.PostForm
+	} else if bodyHash := r.URL.Query().Get("bodySHA256"); bodyHash != "" {
+		// JSON bodies are signed through their hash in the URL
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		if !hmac.Equal([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(bodyHash))) {
+			return fmt.Errorf("%w: body does not match bodySHA256", ErrInvalidSignature)
+		}
+	}
+
+	if !ValidateSignature(v.authToken, rawURL, params, signature) {
+		return fmt.Errorf("%w for %s", ErrInvalidSignature, rawURL)
+	}
+	return nil
+}
+
+// Middleware wraps a handler so that only requests with a valid Twilio signature reach it.
+// Other requests are answered with 403 Forbidden, or 400 Bad Request if the body cannot be read.
+func (v *SignatureValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, webhook.DefaultMaxBodySize)
+		}
+
+		if err := v.Validate(r); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, webhook.ErrInvalidPayload) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// urlVariants returns rawURL and the same URL with the default port added or removed.
+// Only the host is changed, so the path and query keep the encoding Twilio signed.
+func urlVariants(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return []string{rawURL}
+	}
+
+	prefix := u.Scheme + "://" + u.Host
+	rest, ok := strings.CutPrefix(rawURL, prefix)
+	if !ok {
+		return []string{rawURL}
+	}
+
+	defaultPort := map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	switch {
+	case defaultPort == "":
+		return []string{rawURL}
+	case u.Port() == "":
+		return []string{rawURL, u.Scheme + "://" + net.JoinHostPort(u.Hostname(), defaultPort) + rest}
+	case u.Port() == defaultPort:
+		return []string{rawURL, u.Scheme + "://" + u.Hostname() + rest}
+	default:
+		return []string{rawURL}
+	}
+}
+
+// isFormRequest reports whether a request has a form-encoded body
+func isFormRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+// requestScheme returns the scheme a request was received with
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// forwardedValue returns the first value of a proxy header, which may list several hops
+func forwardedValue(r *http.Request, header string) string {
+	value, _, _ := strings.Cut(r.Header.Get(header), ",")
+	return strings.TrimSpace(value)
+}
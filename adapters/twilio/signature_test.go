@@ -0,0 +1,192 @@
+package twilio
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fixed vectors from Twilio's webhook security documentation
+const (
+	testSignatureToken = "12345"
+	testSignatureURL   = "https://mycompany.com/myapp.php?foo=1&bar=2"
+	testFormSignature  = "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+	testJSONBody       = `{"property": "value", "boolean": true}`
+	testJSONBodyHash   = "0a1ff7634d9ab3b95db5c9a2dfe9416e41502b283a80c7cf19632632f96e6620"
+	testJSONSignature  = "a9nBmqA0ju/hNViExpshrM61xv4="
+)
+
+// testSignatureParams are the POST parameters of the form vector
+var testSignatureParams = url.Values{
+	"CallSid": {"CA1234567890ABCDE"},
+	"Caller":  {"+12349013030"},
+	"Digits":  {"1234"},
+	"From":    {"+12349013030"},
+	"To":      {"+18005551212"},
+}
+
+// newSignedRequest builds a form-encoded request to target signed for signedURL
+func newSignedRequest(target, signedURL string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(SignatureHeader, ComputeSignature(testSignatureToken, signedURL, form))
+	return req
+}
+
+// okHandler records whether it was called
+func okHandler(called *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestComputeSignature(t *testing.T) {
+	assert.Equal(t, testFormSignature, ComputeSignature(testSignatureToken, testSignatureURL, testSignatureParams))
+	assert.Equal(t, testJSONSignature,
+		ComputeSignature(testSignatureToken, testSignatureURL+"&bodySHA256="+testJSONBodyHash, nil))
+
+	// Repeated parameters are signed with their values sorted
+	assert.Equal(t,
+		ComputeSignature(testSignatureToken, testSignatureURL, url.Values{"A": {"1", "2"}}),
+		ComputeSignature(testSignatureToken, testSignatureURL, url.Values{"A": {"2", "1"}}))
+}
+
+func TestValidateSignature(t *testing.T) {
+	assert.True(t, ValidateSignature(testSignatureToken, testSignatureURL, testSignatureParams, testFormSignature))
+	assert.False(t, ValidateSignature("wrong", testSignatureURL, testSignatureParams, testFormSignature))
+	assert.False(t, ValidateSignature(testSignatureToken, testSignatureURL+"&baz=3", testSignatureParams, testFormSignature))
+
+	tampered := url.Values{}
+	for key, values := range testSignatureParams {
+		tampered[key] = values
+	}
+	tampered.Set("Digits", "0000")
+	assert.False(t, ValidateSignature(testSignatureToken, testSignatureURL, tampered, testFormSignature))
+
+	// The default port may or may not be part of the signed URL
+	assert.True(t, ValidateSignature(testSignatureToken, "https://mycompany.com:443/myapp.php?foo=1&bar=2",
+		testSignatureParams, testFormSignature))
+	withPort := ComputeSignature(testSignatureToken, "https://mycompany.com:443/myapp.php?foo=1&bar=2", testSignatureParams)
+	assert.True(t, ValidateSignature(testSignatureToken, testSignatureURL, testSignatureParams, withPort))
+	assert.False(t, ValidateSignature(testSignatureToken, "https://mycompany.com:8443/myapp.php?foo=1&bar=2",
+		testSignatureParams, testFormSignature))
+}
+
+func TestSignatureValidatorMiddleware(t *testing.T) {
+	middleware := NewSignatureValidator(testSignatureToken).Middleware
+
+	// Valid signature: the request reaches the handler
+	called := false
+	w := httptest.NewRecorder()
+	middleware(okHandler(&called)).ServeHTTP(w, newSignedRequest(testSignatureURL, testSignatureURL, testSignatureParams))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, called)
+
+	// Wrong signature
+	called = false
+	req := newSignedRequest(testSignatureURL, testSignatureURL, testSignatureParams)
+	req.Header.Set(SignatureHeader, "invalid")
+	w = httptest.NewRecorder()
+	middleware(okHandler(&called)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, called)
+
+	// Missing signature
+	req = newSignedRequest(testSignatureURL, testSignatureURL, testSignatureParams)
+	req.Header.Del(SignatureHeader)
+	w = httptest.NewRecorder()
+	middleware(okHandler(&called)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, called)
+
+	// Signed for another URL
+	w = httptest.NewRecorder()
+	middleware(okHandler(&called)).ServeHTTP(w,
+		newSignedRequest(testSignatureURL, "https://attacker.example/myapp.php?foo=1&bar=2", testSignatureParams))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, called)
+}
+
+func TestSignatureValidatorJSONBody(t *testing.T) {
+	validator := NewSignatureValidator(testSignatureToken)
+	target := testSignatureURL + "&bodySHA256=" + testJSONBodyHash
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(testJSONBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, testJSONSignature)
+	assert.NoError(t, validator.Validate(req))
+
+	// The body stays readable for the next handler
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, testJSONBody, string(body))
+
+	// A body that does not match its hash is rejected
+	req = httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"property": "forged"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, testJSONSignature)
+	assert.ErrorIs(t, validator.Validate(req), ErrInvalidSignature)
+}
+
+func TestSignatureValidatorBehindProxy(t *testing.T) {
+	// Twilio calls the public URL; the proxy forwards to an internal address over plain HTTP
+	const publicURL = "https://api.example.com/sms/webhooks/twilio?foo=1"
+	const internalURL = "http://10.0.0.5:8080/webhooks/twilio?foo=1"
+
+	// Without proxy options the rebuilt URL does not match
+	err := NewSignatureValidator(testSignatureToken).Validate(newSignedRequest(internalURL, publicURL, testSignatureParams))
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	validator := NewSignatureValidator(testSignatureToken, WithPublicURL("https://api.example.com/sms/"))
+	assert.NoError(t, validator.Validate(newSignedRequest(internalURL, publicURL, testSignatureParams)))
+
+	// Forwarded headers, when the path is not rewritten
+	req := newSignedRequest("http://10.0.0.5:8080/webhooks/twilio?foo=1",
+		"https://api.example.com/webhooks/twilio?foo=1", testSignatureParams)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com, 10.0.0.1")
+	assert.NoError(t, NewSignatureValidator(testSignatureToken, WithForwardedHeaders()).Validate(req))
+
+	// Custom URL rebuilding
+	validator = NewSignatureValidator(testSignatureToken, WithURLFunc(func(r *http.Request) string {
+		return publicURL
+	}))
+	assert.NoError(t, validator.Validate(newSignedRequest(internalURL, publicURL, testSignatureParams)))
+}
+
+func TestSignatureValidatorFromConfig(t *testing.T) {
+	cfg := &TwilioConfig{AccountSID: "AC123", AuthToken: testSignatureToken}
+	req := newSignedRequest(testSignatureURL, testSignatureURL, testSignatureParams)
+	assert.NoError(t, cfg.NewSignatureValidator().Validate(req))
+}
+
+func TestSignedDeliveryHandler(t *testing.T) {
+	events := make(chan model.DeliveryEvent, 1)
+	handler := NewSignatureValidator(testSignatureToken).Middleware(
+		webhook.NewDeliveryHandler(webhook.DeliveryParserFunc(ParseDeliveryReport), webhook.ToChannel(events)))
+
+	const callbackURL = "https://example.com/webhooks/twilio/status"
+	form := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {"delivered"}}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(callbackURL, callbackURL, form))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "SM123", (<-events).MessageID)
+
+	// A forged report never reaches the delivery handler
+	req := newSignedRequest(callbackURL, callbackURL, form)
+	req.Header.Set(SignatureHeader, ComputeSignature("wrong", callbackURL, form))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, events)
+}
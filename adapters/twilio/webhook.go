@@ -0,0 +1,93 @@
+package twilio
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+)
+
+// twilioDoneDateLayout is the layout of RawDlrDoneDate (YYMMDDhhmm, UTC)
+const twilioDoneDateLayout = "0601021504"
+
+// ParseDeliveryReport parses a Twilio message status callback, which Twilio posts
+// form-encoded to the StatusCallback URL of a message (the status_callback option).
+// It can be used as a webhook.DeliveryParserFunc.
+func ParseDeliveryReport(r *http.Request) ([]model.DeliveryEvent, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	messageID := firstFormValue(r, "MessageSid", "SmsSid")
+	if messageID == "" {
+		return nil, fmt.Errorf("%w: MessageSid is missing", webhook.ErrInvalidPayload)
+	}
+
+	rawStatus := firstFormValue(r, "MessageStatus", "SmsStatus")
+	if rawStatus == "" {
+		return nil, fmt.Errorf("%w: MessageStatus is missing", webhook.ErrInvalidPayload)
+	}
+
+	// The carrier's delivery time is only reported for some destinations
+	timestamp := time.Now()
+	if doneDate := r.PostForm.Get("RawDlrDoneDate"); doneDate != "" {
+		if t, err := time.Parse(twilioDoneDateLayout, doneDate); err == nil {
+			timestamp = t
+		}
+	}
+
+	return []model.DeliveryEvent{{
+		Provider:  ProviderName,
+		MessageID: messageID,
+		Status:    mapTwilioSMSStatus(rawStatus),
+		RawStatus: rawStatus,
+		ErrorCode: r.PostForm.Get("ErrorCode"),
+		Recipient: r.PostForm.Get("To"),
+		Timestamp: timestamp,
+		Payload:   formPayload(r),
+	}}, nil
+}
+
+// ParseInboundMessage parses an incoming message webhook, which Twilio posts form-encoded
+// to the messaging URL of the receiving number. It can be used as a webhook.InboundParserFunc.
+func ParseInboundMessage(r *http.Request) ([]model.InboundMessage, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidPayload, err)
+	}
+
+	from := r.PostForm.Get("From")
+	if from == "" {
+		return nil, fmt.Errorf("%w: From is missing", webhook.ErrInvalidPayload)
+	}
+
+	return []model.InboundMessage{{
+		Provider:   ProviderName,
+		MessageID:  firstFormValue(r, "MessageSid", "SmsSid"),
+		From:       from,
+		To:         r.PostForm.Get("To"),
+		Body:       r.PostForm.Get("Body"),
+		ReceivedAt: time.Now(),
+		Payload:    formPayload(r),
+	}}, nil
+}
+
+// formPayload returns the POST form values of a request, one value per field
+func formPayload(r *http.Request) map[string]interface{} {
+	payload := make(map[string]interface{}, len(r.PostForm))
+	for key := range r.PostForm {
+		payload[key] = r.PostForm.Get(key)
+	}
+	return payload
+}
+
+// firstFormValue returns the first non-empty POST form value among keys
+func firstFormValue(r *http.Request, keys ...string) string {
+	for _, key := range keys {
+		if value := r.PostForm.Get(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
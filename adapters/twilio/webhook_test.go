@@ -0,0 +1,127 @@
+package twilio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCallbackRequest builds a form-encoded callback request like the ones Twilio sends
+func newCallbackRequest(form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/webhooks/twilio/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestParseDeliveryReport(t *testing.T) {
+	events, err := ParseDeliveryReport(newCallbackRequest(url.Values{
+		"AccountSid":     {"AC123"},
+		"MessageSid":     {"SM123"},
+		"MessageStatus":  {"undelivered"},
+		"ErrorCode":      {"30003"},
+		"To":             {"+14155552671"},
+		"From":           {"+0987654321"},
+		"RawDlrDoneDate": {"2410010830"},
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, ProviderName, event.Provider)
+	assert.Equal(t, "SM123", event.MessageID)
+	assert.Equal(t, model.StatusFailed, event.Status)
+	assert.Equal(t, "undelivered", event.RawStatus)
+	assert.Equal(t, "30003", event.ErrorCode)
+	assert.Equal(t, "+14155552671", event.Recipient)
+	assert.Equal(t, time.Date(2024, 10, 1, 8, 30, 0, 0, time.UTC), event.Timestamp)
+	assert.Equal(t, "AC123", event.Payload["AccountSid"])
+
+	// Older callbacks use the Sms* field names and carry no delivery time
+	events, err = ParseDeliveryReport(newCallbackRequest(url.Values{
+		"SmsSid":    {"SM456"},
+		"SmsStatus": {"delivered"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SM456", events[0].MessageID)
+	assert.Equal(t, model.StatusDelivered, events[0].Status)
+	assert.WithinDuration(t, time.Now(), events[0].Timestamp, time.Minute)
+
+	// Callbacks without a message SID or status are rejected
+	_, err = ParseDeliveryReport(newCallbackRequest(url.Values{"MessageStatus": {"sent"}}))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+	_, err = ParseDeliveryReport(newCallbackRequest(url.Values{"MessageSid": {"SM123"}}))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
+
+func TestDeliveryHandler(t *testing.T) {
+	events := make(chan model.DeliveryEvent, 1)
+	handler := webhook.NewDeliveryHandler(webhook.DeliveryParserFunc(ParseDeliveryReport), webhook.ToChannel(events))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newCallbackRequest(url.Values{
+		"MessageSid":    {"SM123"},
+		"MessageStatus": {"delivered"},
+	}))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "SM123", event.MessageID)
+		assert.Equal(t, model.StatusDelivered, event.Status)
+	default:
+		t.Fatal("Expected a delivery event")
+	}
+}
+
+func TestParseInboundMessage(t *testing.T) {
+	messages, err := ParseInboundMessage(newCallbackRequest(url.Values{
+		"AccountSid": {"AC123"},
+		"MessageSid": {"SM789"},
+		"From":       {"+14155552671"},
+		"To":         {"+18005551212"},
+		"Body":       {"Stop"},
+		"NumMedia":   {"0"},
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	msg := messages[0]
+	assert.Equal(t, ProviderName, msg.Provider)
+	assert.Equal(t, "SM789", msg.MessageID)
+	assert.Equal(t, "+14155552671", msg.From)
+	assert.Equal(t, "+18005551212", msg.To)
+	assert.Equal(t, "Stop", msg.Body)
+	assert.Equal(t, "STOP", msg.Keyword())
+	assert.WithinDuration(t, time.Now(), msg.ReceivedAt, time.Minute)
+	assert.Equal(t, "0", msg.Payload["NumMedia"])
+
+	// Messages without a sender are rejected
+	_, err = ParseInboundMessage(newCallbackRequest(url.Values{"Body": {"Stop"}}))
+	assert.ErrorIs(t, err, webhook.ErrInvalidPayload)
+}
+
+func TestSignedInboundRouter(t *testing.T) {
+	var stopped []string
+	router := webhook.NewKeywordRouter().On("STOP", func(ctx context.Context, msg model.InboundMessage) error {
+		stopped = append(stopped, msg.From)
+		return nil
+	})
+	handler := NewSignatureValidator(testSignatureToken).Middleware(
+		webhook.NewInboundHandler(webhook.InboundParserFunc(ParseInboundMessage), router.Handle))
+
+	const inboundURL = "https://example.com/webhooks/twilio/inbound"
+	form := url.Values{"MessageSid": {"SM789"}, "From": {"+14155552671"}, "To": {"+18005551212"}, "Body": {"stop"}}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(inboundURL, inboundURL, form))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"+14155552671"}, stopped)
+}
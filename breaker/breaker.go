@@ -0,0 +1,302 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOpen is matched by errors.Is when a call is rejected because the circuit is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State string
+
+const (
+	// StateClosed lets every call through and counts failures
+	StateClosed State = "closed"
+
+	// StateOpen rejects every call until the cool-down has elapsed
+	StateOpen State = "open"
+
+	// StateHalfOpen lets a limited number of trial calls through to probe for recovery
+	StateHalfOpen State = "half_open"
+)
+
+// Config holds circuit breaker settings
+type Config struct {
+	// FailureThreshold is the failure rate (between 0 and 1) at which the circuit opens
+	FailureThreshold float64
+
+	// MinRequests is the number of calls needed in a window before the failure rate is evaluated
+	MinRequests int
+
+	// Window is the period over which calls are counted while closed; 0 counts until the circuit opens
+	Window time.Duration
+
+	// CoolDown is how long the circuit stays open before trial calls are allowed
+	CoolDown time.Duration
+
+	// HalfOpenRequests is the number of successful trial calls needed to close the circuit again
+	HalfOpenRequests int
+
+	// IsFailure decides whether a call result counts as a failure
+	// If nil, every non-nil error is a failure
+	IsFailure func(error) bool
+
+	// OnStateChange is called after the circuit changes state
+	OnStateChange func(name string, from, to State)
+}
+
+// DefaultConfig returns a default circuit breaker configuration
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 0.5,
+		MinRequests:      5,
+		Window:           60 * time.Second,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// OpenError is returned by Allow when the circuit rejects a call
+type OpenError struct {
+	// Name is the name of the circuit breaker
+	Name string
+
+	// State is the state that rejected the call (open, or half-open with all trial calls in flight)
+	State State
+
+	// RetryAt is when the circuit will next let a call through
+	RetryAt time.Time
+}
+
+// Error returns the error message
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for '%s' is %s until %s",
+		e.Name, e.State, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrOpen
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// transition records a state change to report once the lock is released
+type transition struct {
+	from, to State
+}
+
+// Breaker is a circuit breaker for one dependency. It is safe for concurrent use.
+// Callers ask Allow before each call and report the result with Record.
+type Breaker struct {
+	// name identifies the breaker in errors and state change notifications
+	name string
+
+	// config holds the breaker settings
+	config Config
+
+	// now returns the current time
+	now func() time.Time
+
+	// mu guards the fields below
+	mu sync.Mutex
+
+	// state is the current state
+	state State
+
+	// requests and failures count the calls in the current window while closed
+	requests, failures int
+
+	// windowStart is when the current counting window began
+	windowStart time.Time
+
+	// openedAt is when the circuit last opened
+	openedAt time.Time
+
+	// trials is the number of trial calls in flight while half-open
+	trials int
+
+	// successes is the number of successful trial calls while half-open
+	successes int
+}
+
+// New creates a closed circuit breaker. Zero settings are replaced by those of DefaultConfig.
+func New(name string, config Config) *Breaker {
+	defaults := DefaultConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaults.MinRequests
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = defaults.CoolDown
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+
+	b := &Breaker{
+		name:   name,
+		config: config,
+		now:    time.Now,
+		state:  StateClosed,
+	}
+	b.windowStart = b.now()
+	return b
+}
+
+// Name returns the name of the breaker
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	changes := b.advance(b.now())
+	state := b.state
+	b.mu.Unlock()
+
+	b.notify(changes)
+	return state
+}
+
+// Allow reports whether a call may proceed. It returns an *OpenError (matching ErrOpen)
+// when the circuit is open, or when it is half-open and all trial calls are in flight.
+// Every allowed call must be followed by Record or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	now := b.now()
+	changes := b.advance(now)
+
+	var err error
+	switch b.state {
+	case StateOpen:
+		err = &OpenError{Name: b.name, State: StateOpen, RetryAt: b.openedAt.Add(b.config.CoolDown)}
+	case StateHalfOpen:
+		if b.trials+b.successes >= b.config.HalfOpenRequests {
+			err = &OpenError{Name: b.name, State: StateHalfOpen, RetryAt: now}
+		} else {
+			b.trials++
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+	return err
+}
+
+// Record reports the result of an allowed call
+func (b *Breaker) Record(err error) {
+	failed := err != nil
+	if b.config.IsFailure != nil {
+		failed = b.config.IsFailure(err)
+	}
+
+	b.mu.Lock()
+	now := b.now()
+	changes := b.advance(now)
+
+	switch b.state {
+	case StateClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.config.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.config.FailureThreshold {
+			changes = append(changes, b.open(now))
+		}
+
+	case StateHalfOpen:
+		if b.trials > 0 {
+			b.trials--
+		}
+		if failed {
+			changes = append(changes, b.open(now))
+		} else {
+			b.successes++
+			if b.successes >= b.config.HalfOpenRequests {
+				changes = append(changes, b.close(now))
+			}
+		}
+
+	case StateOpen:
+		// The call started before the circuit opened; its result no longer matters
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+}
+
+// Release gives back an allowed call without counting it, e.g. when the caller canceled it
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	if b.state == StateHalfOpen && b.trials > 0 {
+		b.trials--
+	}
+	b.mu.Unlock()
+}
+
+// Reset closes the circuit and clears its counts
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	var changes []transition
+	if b.state != StateClosed {
+		changes = append(changes, b.close(b.now()))
+	} else {
+		b.requests, b.failures, b.windowStart = 0, 0, b.now()
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+}
+
+// advance moves an open circuit to half-open after the cool-down and starts a new
+// counting window when the current one has elapsed. It must be called with mu held.
+func (b *Breaker) advance(now time.Time) []transition {
+	switch b.state {
+	case StateOpen:
+		if !now.Before(b.openedAt.Add(b.config.CoolDown)) {
+			b.trials, b.successes = 0, 0
+			b.state = StateHalfOpen
+			return []transition{{from: StateOpen, to: StateHalfOpen}}
+		}
+	case StateClosed:
+		if b.config.Window > 0 && !now.Before(b.windowStart.Add(b.config.Window)) {
+			b.requests, b.failures, b.windowStart = 0, 0, now
+		}
+	}
+	return nil
+}
+
+// open trips the circuit. It must be called with mu held.
+func (b *Breaker) open(now time.Time) transition {
+	from := b.state
+	b.state = StateOpen
+	b.openedAt = now
+	b.trials, b.successes = 0, 0
+	return transition{from: from, to: StateOpen}
+}
+
+// close closes the circuit and starts a new counting window. It must be called with mu held.
+func (b *Breaker) close(now time.Time) transition {
+	from := b.state
+	b.state = StateClosed
+	b.requests, b.failures, b.windowStart = 0, 0, now
+	b.trials, b.successes = 0, 0
+	return transition{from: from, to: StateClosed}
+}
+
+// notify reports state changes to OnStateChange outside the lock
+func (b *Breaker) notify(changes []transition) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		b.config.OnStateChange(b.name, change.from, change.to)
+	}
+}
@@ -0,0 +1,165 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for breaker tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestBreaker creates a breaker driven by a fake clock that records its state changes
+func newTestBreaker(config Config) (*Breaker, *fakeClock, *[]State) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var changes []State
+	config.OnStateChange = func(name string, from, to State) {
+		changes = append(changes, to)
+	}
+
+	b := New("esms", config)
+	b.now = clock.Now
+	b.windowStart = clock.Now()
+	return b, clock, &changes
+}
+
+// call runs one call through the breaker and reports whether it was allowed
+func call(b *Breaker, err error) bool {
+	if b.Allow() != nil {
+		return false
+	}
+	b.Record(err)
+	return true
+}
+
+func TestBreakerOpensOnFailureRate(t *testing.T) {
+	b, _, changes := newTestBreaker(Config{FailureThreshold: 0.5, MinRequests: 4, CoolDown: time.Minute})
+	failure := errors.New("unavailable")
+
+	call(b, nil)
+	call(b, failure)
+	call(b, nil)
+	if b.State() != StateClosed {
+		t.Fatalf("Expected closed before MinRequests, got %s", b.State())
+	}
+
+	call(b, failure)
+	if b.State() != StateOpen {
+		t.Fatalf("Expected open at 50%% failures, got %s", b.State())
+	}
+
+	err := b.Allow()
+	if !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected ErrOpen, got %v", err)
+	}
+	var openErr *OpenError
+	if !errors.As(err, &openErr) || openErr.Name != "esms" || openErr.State != StateOpen {
+		t.Errorf("Expected OpenError for esms, got %v", err)
+	}
+
+	if len(*changes) != 1 || (*changes)[0] != StateOpen {
+		t.Errorf("Expected one change to open, got %v", *changes)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	config := Config{FailureThreshold: 1, MinRequests: 1, CoolDown: time.Minute, HalfOpenRequests: 2}
+	failure := errors.New("unavailable")
+
+	t.Run("Closes after successful trials", func(t *testing.T) {
+		b, clock, changes := newTestBreaker(config)
+		call(b, failure)
+
+		clock.Advance(59 * time.Second)
+		if b.Allow() == nil {
+			t.Fatal("Expected calls to be rejected during the cool-down")
+		}
+
+		clock.Advance(time.Second)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected a trial call after the cool-down, got %v", err)
+		}
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected a second trial call, got %v", err)
+		}
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Fatalf("Expected extra calls to be rejected while trials are in flight, got %v", err)
+		}
+
+		b.Record(nil)
+		b.Record(nil)
+		if b.State() != StateClosed {
+			t.Fatalf("Expected closed after successful trials, got %s", b.State())
+		}
+
+		expected := []State{StateOpen, StateHalfOpen, StateClosed}
+		if len(*changes) != len(expected) {
+			t.Fatalf("Expected changes %v, got %v", expected, *changes)
+		}
+		for i := range expected {
+			if (*changes)[i] != expected[i] {
+				t.Errorf("Expected changes %v, got %v", expected, *changes)
+			}
+		}
+	})
+
+	t.Run("Reopens on a failed trial", func(t *testing.T) {
+		b, clock, _ := newTestBreaker(config)
+		call(b, failure)
+		clock.Advance(time.Minute)
+
+		call(b, failure)
+		if b.State() != StateOpen {
+			t.Fatalf("Expected open after a failed trial, got %s", b.State())
+		}
+	})
+
+	t.Run("Released trials are not counted", func(t *testing.T) {
+		b, clock, _ := newTestBreaker(Config{FailureThreshold: 1, MinRequests: 1, CoolDown: time.Minute})
+		call(b, failure)
+		clock.Advance(time.Minute)
+
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected a trial call, got %v", err)
+		}
+		b.Release()
+		if b.State() != StateHalfOpen {
+			t.Fatalf("Expected still half-open, got %s", b.State())
+		}
+		if err := b.Allow(); err != nil {
+			t.Errorf("Expected the released trial slot to be available, got %v", err)
+		}
+	})
+}
+
+func TestBreakerWindow(t *testing.T) {
+	b, clock, _ := newTestBreaker(Config{FailureThreshold: 0.5, MinRequests: 2, Window: time.Minute})
+	failure := errors.New("unavailable")
+
+	call(b, failure)
+	clock.Advance(time.Minute)
+	call(b, nil)
+	if b.State() != StateClosed {
+		t.Errorf("Expected failures from an earlier window to be forgotten, got %s", b.State())
+	}
+}
+
+func TestBreakerIsFailure(t *testing.T) {
+	ignored := errors.New("invalid recipient")
+	b, _, _ := newTestBreaker(Config{
+		FailureThreshold: 1,
+		MinRequests:      1,
+		IsFailure:        func(err error) bool { return err != nil && err != ignored },
+	})
+
+	call(b, ignored)
+	if b.State() != StateClosed {
+		t.Errorf("Expected errors rejected by IsFailure not to open the circuit, got %s", b.State())
+	}
+}
@@ -0,0 +1,141 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-fork/sms/breaker"
+	"github.com/go-fork/sms/model"
+)
+
+// BreakerHook is called after the circuit breaker of a provider changes state
+type BreakerHook func(provider string, from, to breaker.State)
+
+// SetBreakerHook registers a function that is called after a provider's circuit breaker
+// changes state, e.g. to log or alert when a provider is taken out of rotation.
+// Passing nil removes the hook.
+func (m *Module) SetBreakerHook(hook BreakerHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.breakerHook = hook
+}
+
+// GetBreakerState returns the circuit breaker state of a registered provider.
+// Providers are reported as closed when circuit breaking is disabled.
+func (m *Module) GetBreakerState(name string) (breaker.State, error) {
+	m.mu.RLock()
+	_, exists := m.providers[name]
+	m.mu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("provider '%s' not found", name)
+	}
+
+	if b := m.providerBreaker(name); b != nil {
+		return b.State(), nil
+	}
+	return breaker.StateClosed, nil
+}
+
+// ResetBreaker closes the circuit breaker of a provider, e.g. after it was fixed manually
+func (m *Module) ResetBreaker(name string) error {
+	if _, err := m.GetBreakerState(name); err != nil {
+		return err
+	}
+
+	if b := m.providerBreaker(name); b != nil {
+		b.Reset()
+	}
+	return nil
+}
+
+// callProvider runs a single call to a provider through its rate limiter and circuit breaker.
+// When the circuit is open, or no rate limit token is free with failFast, the call is rejected
+// with an error matching breaker.ErrOpen or ratelimit.ErrRateLimited. These errors are not
+// retried, so the send fails fast or moves on to the next failover provider.
+func (m *Module) callProvider(ctx context.Context, name string, failFast bool, call func() error) error {
+	if err := m.acquireProviderToken(ctx, name, failFast); err != nil {
+		return err
+	}
+
+	b := m.providerBreaker(name)
+	if b == nil {
+		return call()
+	}
+
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := call()
+
+	// Calls cut short by the caller say nothing about the provider's health
+	if ctx.Err() != nil {
+		b.Release()
+		return err
+	}
+
+	b.Record(err)
+	return err
+}
+
+// providerBreaker returns the circuit breaker of a provider, creating it on first use,
+// or nil when circuit breaking is disabled
+func (m *Module) providerBreaker(name string) *breaker.Breaker {
+	settings := m.config.GetCircuitBreaker()
+	if !settings.Enabled {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, ok := m.breakers[name]; ok {
+		return b
+	}
+
+	if m.breakers == nil {
+		m.breakers = make(map[string]*breaker.Breaker)
+	}
+
+	b := breaker.New(name, breaker.Config{
+		FailureThreshold: settings.FailureThreshold,
+		MinRequests:      settings.MinRequests,
+		Window:           settings.Window,
+		CoolDown:         settings.CoolDown,
+		HalfOpenRequests: settings.HalfOpenRequests,
+		IsFailure:        isProviderFailure,
+		OnStateChange:    m.breakerStateChanged,
+	})
+	m.breakers[name] = b
+
+	return b
+}
+
+// breakerStateChanged forwards circuit breaker state changes to the registered hook
+func (m *Module) breakerStateChanged(provider string, from, to breaker.State) {
+	m.mu.RLock()
+	hook := m.breakerHook
+	m.mu.RUnlock()
+
+	if hook != nil {
+		hook(provider, from, to)
+	}
+}
+
+// isProviderFailure reports whether an error says the provider is unhealthy.
+// Errors caused by the request itself, such as an invalid recipient, do not count.
+func isProviderFailure(err error) bool {
+	if err == nil || errors.Is(err, breaker.ErrOpen) {
+		return false
+	}
+
+	switch model.ErrorCategoryOf(err) {
+	case model.ErrorCategoryInvalidRecipient, model.ErrorCategoryOptedOut, model.ErrorCategoryContentRejected,
+		model.ErrorCategoryInvalidRequest:
+		return false
+	}
+	return true
+}
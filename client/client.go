@@ -2,14 +2,32 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-fork/sms/config"
+	"github.com/go-fork/sms/retry"
 	"github.com/go-resty/resty/v2"
 )
 
+const (
+	// DefaultMaxIdleConns is the default maximum number of idle connections across all hosts
+	DefaultMaxIdleConns = 100
+
+	// DefaultMaxIdleConnsPerHost is the default maximum number of idle connections per host
+	DefaultMaxIdleConnsPerHost = 20
+
+	// DefaultIdleConnTimeout is the default time an idle connection is kept open
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
 // Client represents an HTTP client for making API requests to providers
 type Client struct {
 	// restyClient is the underlying HTTP client from the resty library
@@ -19,26 +37,81 @@ type Client struct {
 	config config.ConfigProvider
 }
 
-// NewClient creates a new HTTP client with the provided configuration
-func NewClient(cfg config.ConfigProvider) *Client {
-	if cfg == nil {
-		// Return an error rather than trying to create a default configuration
-		// This makes it clear that a valid configuration is required
-		panic("config cannot be nil, must provide a valid configuration")
+// Option customizes a Client created by NewClient
+type Option func(*options)
+
+// options holds the settings collected from Option values
+type options struct {
+	// httpClient replaces the underlying *http.Client
+	httpClient *http.Client
+
+	// transport replaces the transport built from the configuration
+	transport http.RoundTripper
+}
+
+// WithHTTPClient makes the client send requests through an existing *http.Client.
+// Its transport is used as-is, so the configured proxy, TLS and connection settings do not apply;
+// the configured timeout only applies when the client has none.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTransport makes the client send requests through an http.RoundTripper, such as an
+// httptest server transport or an instrumented transport. The configured proxy, TLS and
+// connection settings do not apply.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// NewClient creates a new HTTP client with the provided configuration.
+// A nil configuration uses the default timeout and transport settings.
+// It returns an error when the proxy or TLS settings cannot be applied.
+func NewClient(cfg config.ConfigProvider, opts ...Option) (*Client, error) {
+	if c, ok := cfg.(*config.Config); ok && c == nil {
+		cfg = nil
 	}
 
-	// Create a new resty client with the provided configuration
-	restyClient := resty.New()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	// Configure timeouts
-	restyClient.SetTimeout(cfg.GetHTTPTimeout())
+	timeout := config.DefaultHTTPTimeout
+	var settings config.HTTPConfig
+	if cfg != nil {
+		if cfg.GetHTTPTimeout() > 0 {
+			timeout = cfg.GetHTTPTimeout()
+		}
+		if provider, ok := cfg.(config.HTTPConfigProvider); ok {
+			settings = provider.GetHTTPConfig()
+		}
+	}
 
-	// Set reasonable defaults for connections
-	restyClient.SetTransport(&http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 20,
-		IdleConnTimeout:     90 * time.Second,
-	})
+	// Create a new resty client on the given or a new http.Client
+	var restyClient *resty.Client
+	switch {
+	case o.httpClient != nil:
+		restyClient = resty.NewWithClient(o.httpClient)
+		if o.httpClient.Timeout == 0 {
+			restyClient.SetTimeout(timeout)
+		}
+	case o.transport != nil:
+		restyClient = resty.New()
+		restyClient.SetTransport(o.transport)
+		restyClient.SetTimeout(timeout)
+	default:
+		transport, err := NewTransport(settings)
+		if err != nil {
+			return nil, err
+		}
+		restyClient = resty.New()
+		restyClient.SetTransport(transport)
+		restyClient.SetTimeout(timeout)
+	}
 
 	// Disable resty's built-in retry to use our custom retry logic
 	restyClient.SetRetryCount(0)
@@ -46,7 +119,91 @@ func NewClient(cfg config.ConfigProvider) *Client {
 	return &Client{
 		restyClient: restyClient,
 		config:      cfg,
+	}, nil
+}
+
+// NewTransport builds an *http.Transport from the proxy, TLS and connection settings
+func NewTransport(settings config.HTTPConfig) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		MaxConnsPerHost:     settings.MaxConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if settings.MaxIdleConns > 0 {
+		transport.MaxIdleConns = settings.MaxIdleConns
+	}
+	if settings.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+	}
+	if settings.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = settings.IdleConnTimeout
+	}
+
+	if settings.Proxy != "" {
+		proxyURL, err := url.Parse(settings.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s'", settings.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(settings.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// newTLSConfig builds the TLS configuration, or returns nil when the defaults apply
+func newTLSConfig(settings config.TLSConfig) (*tls.Config, error) {
+	if settings == (config.TLSConfig{}) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	switch settings.MinVersion {
+	case "":
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported TLS min version '%s'", settings.MinVersion)
+	}
+
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file '%s'", settings.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
+
+	return tlsConfig, nil
 }
 
 // R returns a new request object for building and executing requests
@@ -101,9 +258,45 @@ func (c *Client) ProcessResponse(resp *resty.Response, err error) ([]byte, error
 
 	// Check for HTTP errors
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("HTTP error: status code: %d, body: %s",
-			resp.StatusCode(), resp.String())
+		return nil, &retry.HTTPError{
+			StatusCode: resp.StatusCode(),
+			Message:    resp.String(),
+			RetryAfter: RetryAfter(resp),
+		}
 	}
 
 	return resp.Body(), nil
 }
+
+// RetryAfter returns the delay a response asks the client to wait before retrying,
+// read from its Retry-After header, or 0 if the header is missing or invalid
+func RetryAfter(resp *resty.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	return ParseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+}
+
+// ParseRetryAfter parses a Retry-After header value, given either as a number of
+// seconds or as an HTTP date, relative to now. It returns 0 for empty, invalid or past values.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitBreakerConfig configures the circuit breaker kept for each provider
+type CircuitBreakerConfig struct {
+	// Enabled turns on circuit breaking for every provider
+	Enabled bool `mapstructure:"enabled"`
+
+	// FailureThreshold is the failure rate (between 0 and 1) at which a provider's circuit opens
+	FailureThreshold float64 `mapstructure:"failure_threshold"`
+
+	// MinRequests is the number of sends needed in a window before the failure rate is evaluated
+	MinRequests int `mapstructure:"min_requests"`
+
+	// Window is the period over which sends are counted
+	Window time.Duration `mapstructure:"window"`
+
+	// CoolDown is how long a circuit stays open before a trial send is allowed
+	CoolDown time.Duration `mapstructure:"cool_down"`
+
+	// HalfOpenRequests is the number of successful trial sends needed to close the circuit
+	HalfOpenRequests int `mapstructure:"half_open_requests"`
+}
+
+// GetCircuitBreaker returns the circuit breaker configuration
+func (c *Config) GetCircuitBreaker() CircuitBreakerConfig {
+	return c.CircuitBreaker
+}
+
+// validate checks the circuit breaker settings; zero values select the defaults
+func (b CircuitBreakerConfig) validate() error {
+	if b.FailureThreshold < 0 || b.FailureThreshold > 1 {
+		return fmt.Errorf("circuit_breaker: failure_threshold must be between 0 and 1, got %v", b.FailureThreshold)
+	}
+	if b.MinRequests < 0 {
+		return fmt.Errorf("circuit_breaker: min_requests must be non-negative, got %d", b.MinRequests)
+	}
+	if b.Window < 0 {
+		return fmt.Errorf("circuit_breaker: window must be non-negative, got %s", b.Window)
+	}
+	if b.CoolDown < 0 {
+		return fmt.Errorf("circuit_breaker: cool_down must be non-negative, got %s", b.CoolDown)
+	}
+	if b.HalfOpenRequests < 0 {
+		return fmt.Errorf("circuit_breaker: half_open_requests must be non-negative, got %d", b.HalfOpenRequests)
+	}
+	return nil
+}
@@ -3,8 +3,12 @@ package config
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/retry"
 	"github.com/spf13/viper"
 )
 
@@ -18,6 +22,12 @@ const (
 	// DefaultRetryDelay is the default delay between retries
 	DefaultRetryDelay = 500 * time.Millisecond
 
+	// DefaultRetryMaxDelay is the default maximum delay between retries
+	DefaultRetryMaxDelay = 30 * time.Second
+
+	// DefaultRetryMultiplier is the default factor by which the retry delay grows
+	DefaultRetryMultiplier = 2.0
+
 	// DefaultSMSTemplate is the default template for SMS messages
 	DefaultSMSTemplate = "Your message is {message}"
 
@@ -42,11 +52,20 @@ var (
 	// ErrInvalidRetryDelay indicates an invalid retry delay value
 	ErrInvalidRetryDelay = errors.New("retry delay must be greater than 0")
 
+	// ErrInvalidRetryMaxDelay indicates an invalid maximum retry delay value
+	ErrInvalidRetryMaxDelay = errors.New("retry max delay must not be less than the retry delay")
+
+	// ErrInvalidRetryMultiplier indicates an invalid retry multiplier value
+	ErrInvalidRetryMultiplier = errors.New("retry multiplier must be at least 1")
+
 	// ErrMissingSMSTemplate indicates a missing SMS template
 	ErrMissingSMSTemplate = errors.New("SMS template is required")
 
 	// ErrMissingVoiceTemplate indicates a missing voice template
 	ErrMissingVoiceTemplate = errors.New("voice template is required")
+
+	// ErrTemplateNotFound indicates that a named template or locale variant is not registered
+	ErrTemplateNotFound = errors.New("template not found")
 )
 
 // ConfigProvider defines the interface for configuration access
@@ -76,12 +95,25 @@ type Config struct {
 	// HTTPTimeout is the timeout for HTTP requests
 	HTTPTimeout time.Duration `mapstructure:"http_timeout"`
 
+	// HTTP configures the proxy, TLS and connection settings of the HTTP client
+	HTTP HTTPConfig `mapstructure:"http"`
+
 	// RetryAttempts is the number of retry attempts
 	RetryAttempts int `mapstructure:"retry_attempts"`
 
 	// RetryDelay is the delay between retries
 	RetryDelay time.Duration `mapstructure:"retry_delay"`
 
+	// RetryMaxDelay is the maximum delay between retries (0 means DefaultRetryMaxDelay)
+	RetryMaxDelay time.Duration `mapstructure:"retry_max_delay"`
+
+	// RetryMultiplier is the factor by which the delay grows after each retry (0 means DefaultRetryMultiplier)
+	RetryMultiplier float64 `mapstructure:"retry_multiplier"`
+
+	// RetryStrategy is the backoff strategy: exponential (default), full_jitter, equal_jitter,
+	// decorrelated_jitter or constant
+	RetryStrategy string `mapstructure:"retry_strategy"`
+
 	// SMSTemplate is the default template for SMS messages
 	SMSTemplate string `mapstructure:"sms_template"`
 
@@ -90,6 +122,37 @@ type Config struct {
 
 	// Providers contains provider-specific configurations
 	Providers map[string]interface{} `mapstructure:"providers"`
+
+	// Failover is the ordered list of providers to try when the active provider fails
+	Failover []string `mapstructure:"failover"`
+
+	// Templates is the named template registry, mapping each template name to its text per locale
+	Templates map[string]map[string]string `mapstructure:"templates"`
+
+	// DefaultLocale is the locale used when a request does not specify one
+	DefaultLocale string `mapstructure:"default_locale"`
+
+	// StrictTemplates makes rendering fail for every request when a placeholder has no value
+	StrictTemplates bool `mapstructure:"strict_templates"`
+
+	// Transliterate rewrites SMS bodies to GSM-7 (e.g. strips Vietnamese diacritics) before sending
+	// It can be overridden per provider with providers.<name>.transliterate and per request
+	Transliterate bool `mapstructure:"transliterate"`
+
+	// DefaultRegion is the region (e.g. "VN") used to read recipient numbers in national format
+	DefaultRegion string `mapstructure:"default_region"`
+
+	// Routes are evaluated in order; the first rule that matches a message picks its provider
+	Routes []RouteRule `mapstructure:"routes"`
+
+	// CircuitBreaker configures the circuit breaker kept for each provider
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+
+	// RateLimits configures the per-provider and per-recipient rate limits
+	RateLimits RateLimitConfig `mapstructure:"rate_limits"`
+
+	// Suppression configures the list of numbers that opted out of SMS messages
+	Suppression SuppressionConfig `mapstructure:"suppression"`
 }
 
 // Implement ConfigProvider interface
@@ -109,6 +172,22 @@ func (c *Config) GetRetryDelay() time.Duration {
 	return c.RetryDelay
 }
 
+// GetRetryMaxDelay returns the maximum delay between retries
+func (c *Config) GetRetryMaxDelay() time.Duration {
+	if c.RetryMaxDelay == 0 {
+		return DefaultRetryMaxDelay
+	}
+	return c.RetryMaxDelay
+}
+
+// GetRetryMultiplier returns the factor by which the retry delay grows
+func (c *Config) GetRetryMultiplier() float64 {
+	if c.RetryMultiplier == 0 {
+		return DefaultRetryMultiplier
+	}
+	return c.RetryMultiplier
+}
+
 // GetDefaultProvider returns the name of the default provider
 func (c *Config) GetDefaultProvider() string {
 	return c.DefaultProvider
@@ -124,6 +203,121 @@ func (c *Config) GetVoiceTemplate() string {
 	return c.VoiceTemplate
 }
 
+// GetDefaultRegion returns the region used to read recipient numbers in national format
+func (c *Config) GetDefaultRegion() string {
+	return c.DefaultRegion
+}
+
+// GetDefaultLocale returns the default template locale
+func (c *Config) GetDefaultLocale() string {
+	return c.DefaultLocale
+}
+
+// GetStrictTemplates reports whether strict template rendering is enabled globally
+func (c *Config) GetStrictTemplates() bool {
+	return c.StrictTemplates
+}
+
+// GetTransliterate reports whether SMS bodies should be transliterated to GSM-7 for a provider.
+// The provider's own transliterate setting takes precedence over the global one.
+func (c *Config) GetTransliterate(providerName string) bool {
+	if providerConfig, err := c.GetProviderConfig(providerName); err == nil {
+		if transliterate, ok := providerConfig["transliterate"].(bool); ok {
+			return transliterate
+		}
+	}
+
+	return c.Transliterate
+}
+
+// GetTemplate returns the text of a named template for the given locale.
+// The locale falls back from its full form (vi-VN) to its language (vi),
+// then to the default locale and its language. Names and locales are case insensitive
+// once the configuration has been validated.
+func (c *Config) GetTemplate(name, locale string) (string, error) {
+	variants, ok := c.Templates[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrTemplateNotFound, name)
+	}
+
+	for _, candidate := range localeFallbacks(locale, c.DefaultLocale) {
+		if text, ok := variants[candidate]; ok {
+			return text, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s' has no variant for locale '%s'", ErrTemplateNotFound, name, locale)
+}
+
+// ListTemplates returns the registered template names with their sorted locales
+func (c *Config) ListTemplates() map[string][]string {
+	templates := make(map[string][]string, len(c.Templates))
+	for name, variants := range c.Templates {
+		locales := make([]string, 0, len(variants))
+		for locale := range variants {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+		templates[name] = locales
+	}
+
+	return templates
+}
+
+// normalizeTemplates rewrites the template registry with lowercase names and normalized
+// locales, so that templates registered in code are found like those loaded by Viper,
+// which lowercases keys. Names or locales that only differ in case are rejected.
+func (c *Config) normalizeTemplates() error {
+	if len(c.Templates) == 0 {
+		return nil
+	}
+
+	templates := make(map[string]map[string]string, len(c.Templates))
+	for name, variants := range c.Templates {
+		key := strings.ToLower(name)
+		if _, exists := templates[key]; exists {
+			return fmt.Errorf("template '%s' is defined more than once", key)
+		}
+
+		normalized := make(map[string]string, len(variants))
+		for locale, text := range variants {
+			l := normalizeLocale(locale)
+			if _, exists := normalized[l]; exists {
+				return fmt.Errorf("template '%s' has more than one variant for locale '%s'", key, l)
+			}
+			normalized[l] = text
+		}
+		templates[key] = normalized
+	}
+
+	c.Templates = templates
+	return nil
+}
+
+// normalizeLocale lowercases a locale and writes it with "-" (vi_VN becomes vi-vn)
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(locale), "_", "-")
+}
+
+// localeFallbacks returns the locales to look up, in order, for a requested locale
+func localeFallbacks(locale, defaultLocale string) []string {
+	var candidates []string
+	for _, l := range []string{locale, defaultLocale} {
+		// Registry locales are normalized when the configuration is validated
+		l = normalizeLocale(l)
+		if l == "" {
+			continue
+		}
+
+		candidates = append(candidates, l)
+		if i := strings.Index(l, "-"); i > 0 {
+			candidates = append(candidates, l[:i])
+		}
+	}
+
+	return candidates
+}
+
 // LoadConfig loads configuration from the specified file path
 func LoadConfig(configFile string) (*Config, error) {
 	v := viper.New()
@@ -132,6 +326,8 @@ func LoadConfig(configFile string) (*Config, error) {
 	v.SetDefault("http_timeout", DefaultHTTPTimeout)
 	v.SetDefault("retry_attempts", DefaultRetryAttempts)
 	v.SetDefault("retry_delay", DefaultRetryDelay)
+	v.SetDefault("retry_max_delay", DefaultRetryMaxDelay)
+	v.SetDefault("retry_multiplier", DefaultRetryMultiplier)
 	v.SetDefault("sms_template", DefaultSMSTemplate)
 	v.SetDefault("voice_template", DefaultVoiceTemplate)
 
@@ -158,6 +354,13 @@ func LoadConfig(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("invalid retry_delay format: %s", retryDelayStr)
 	}
 
+	retryMaxDelayStr := v.GetString("retry_max_delay")
+	if retryMaxDelay, err := time.ParseDuration(retryMaxDelayStr); err == nil {
+		v.Set("retry_max_delay", retryMaxDelay)
+	} else {
+		return nil, fmt.Errorf("invalid retry_max_delay format: %s", retryMaxDelayStr)
+	}
+
 	// Unmarshal config into struct
 	config := &Config{}
 	if err := v.Unmarshal(config); err != nil {
@@ -191,6 +394,29 @@ func (c *Config) GetProviderConfig(providerName string) (map[string]interface{},
 	return config, nil
 }
 
+// GetProviderSMSTemplate returns the sms_template configured for a specific provider
+// It returns an empty string if the provider has no template of its own
+func (c *Config) GetProviderSMSTemplate(providerName string) string {
+	return c.providerString(providerName, "sms_template")
+}
+
+// GetProviderVoiceTemplate returns the voice_template configured for a specific provider
+// It returns an empty string if the provider has no template of its own
+func (c *Config) GetProviderVoiceTemplate(providerName string) string {
+	return c.providerString(providerName, "voice_template")
+}
+
+// providerString returns a string field from a provider's configuration, or an empty string
+func (c *Config) providerString(providerName, field string) string {
+	providerConfig, err := c.GetProviderConfig(providerName)
+	if err != nil {
+		return ""
+	}
+
+	value, _ := providerConfig[field].(string)
+	return value
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	// Validate default provider
@@ -208,11 +434,51 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("default provider '%s' not found in configured providers", c.DefaultProvider)
 	}
 
+	// Verify that every failover provider exists in the configured providers
+	for _, name := range c.Failover {
+		if _, ok := c.Providers[name]; !ok {
+			return fmt.Errorf("failover provider '%s' not found in configured providers", name)
+		}
+	}
+
+	// Validate the default region
+	if c.DefaultRegion != "" && !model.IsSupportedRegion(c.DefaultRegion) {
+		return fmt.Errorf("unsupported default region '%s' (supported: %s)",
+			c.DefaultRegion, strings.Join(model.SupportedRegions(), ", "))
+	}
+
+	// Validate routing rules
+	routeNames := make(map[string]bool, len(c.Routes))
+	for i, rule := range c.Routes {
+		if rule.Name == "" {
+			return fmt.Errorf("route %d: name is required", i+1)
+		}
+		if routeNames[rule.Name] {
+			return fmt.Errorf("duplicate route '%s'", rule.Name)
+		}
+		routeNames[rule.Name] = true
+
+		if _, ok := c.Providers[rule.Provider]; !ok {
+			return fmt.Errorf("route '%s': provider '%s' not found in configured providers", rule.Name, rule.Provider)
+		}
+
+		for _, carrier := range rule.Carriers {
+			if !model.IsKnownCarrier(model.Carrier(strings.ToLower(carrier))) {
+				return fmt.Errorf("route '%s': unknown carrier '%s'", rule.Name, carrier)
+			}
+		}
+	}
+
 	// Validate HTTP timeout
 	if c.HTTPTimeout <= 0 {
 		return ErrInvalidHTTPTimeout
 	}
 
+	// Validate the HTTP transport settings
+	if err := c.HTTP.validate(); err != nil {
+		return err
+	}
+
 	// Validate retry attempts (0 means no retries, which is valid)
 	if c.RetryAttempts < 0 {
 		return ErrInvalidRetryAttempts
@@ -223,6 +489,33 @@ func (c *Config) Validate() error {
 		return ErrInvalidRetryDelay
 	}
 
+	// Validate the backoff settings
+	if c.RetryMaxDelay < 0 || (c.RetryMaxDelay > 0 && c.RetryMaxDelay < c.RetryDelay) {
+		return ErrInvalidRetryMaxDelay
+	}
+	if c.RetryMultiplier != 0 && c.RetryMultiplier < 1 {
+		return ErrInvalidRetryMultiplier
+	}
+	if !retry.Strategy(c.RetryStrategy).IsValid() {
+		return fmt.Errorf("unknown retry strategy '%s' (supported: %s)",
+			c.RetryStrategy, joinStrategies(retry.Strategies()))
+	}
+
+	// Validate the circuit breaker settings
+	if err := c.CircuitBreaker.validate(); err != nil {
+		return err
+	}
+
+	// Validate the rate limits
+	if err := c.RateLimits.validate(c.Providers); err != nil {
+		return err
+	}
+
+	// Validate the suppression list settings
+	if err := c.Suppression.validate(); err != nil {
+		return err
+	}
+
 	// Validate SMS template
 	if c.SMSTemplate == "" {
 		return ErrMissingSMSTemplate
@@ -233,9 +526,34 @@ func (c *Config) Validate() error {
 		return ErrMissingVoiceTemplate
 	}
 
+	// Validate the template registry
+	if err := c.normalizeTemplates(); err != nil {
+		return err
+	}
+	for name, variants := range c.Templates {
+		if len(variants) == 0 {
+			return fmt.Errorf("template '%s' has no locale variants", name)
+		}
+
+		for locale, text := range variants {
+			if err := model.ValidateTemplate(text); err != nil {
+				return fmt.Errorf("invalid template '%s' for locale '%s': %w", name, locale, err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// joinStrategies formats retry strategies for error messages
+func joinStrategies(strategies []retry.Strategy) string {
+	names := make([]string, len(strategies))
+	for i, strategy := range strategies {
+		names[i] = string(strategy)
+	}
+	return strings.Join(names, ", ")
+}
+
 // ValidateProviderConfig validates provider-specific configuration
 // This is a helper function that providers can use to validate their configurations
 func ValidateProviderConfig(config map[string]interface{}, requiredFields ...string) error {
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// HTTPConfig configures the transport of the HTTP client used by the providers
+type HTTPConfig struct {
+	// Proxy is the URL of the proxy to send requests through (e.g. "http://proxy.corp:3128")
+	// If empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used
+	Proxy string `mapstructure:"proxy"`
+
+	// TLS configures certificate verification and client certificates
+	TLS TLSConfig `mapstructure:"tls"`
+
+	// MaxIdleConns is the maximum number of idle connections across all hosts (0 means 100)
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per host (0 means 20)
+	MaxIdleConnsPerHost int `mapstructure:"max_idle_conns_per_host"`
+
+	// MaxConnsPerHost limits the connections per host, including those in use (0 means no limit)
+	MaxConnsPerHost int `mapstructure:"max_conns_per_host"`
+
+	// IdleConnTimeout is how long an idle connection is kept open (0 means 90s)
+	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
+}
+
+// TLSConfig configures TLS for provider requests
+type TLSConfig struct {
+	// CAFile is a PEM file of additional root certificates to trust (e.g. a corporate CA)
+	CAFile string `mapstructure:"ca_file"`
+
+	// CertFile and KeyFile are the PEM client certificate and key used for mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// MinVersion is the minimum TLS version: "1.2" or "1.3" (empty uses the Go default)
+	MinVersion string `mapstructure:"min_version"`
+
+	// InsecureSkipVerify disables certificate verification; only use it for testing
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// HTTPConfigProvider is implemented by configurations that carry HTTP transport settings.
+// It is separate from ConfigProvider so that existing implementations keep compiling;
+// client.NewClient applies the settings when its configuration implements it.
+type HTTPConfigProvider interface {
+	GetHTTPConfig() HTTPConfig
+}
+
+// GetHTTPConfig returns the HTTP transport configuration
+func (c *Config) GetHTTPConfig() HTTPConfig {
+	return c.HTTP
+}
+
+// validate checks the HTTP transport settings
+func (h HTTPConfig) validate() error {
+	if h.Proxy != "" {
+		proxyURL, err := url.Parse(h.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return fmt.Errorf("http: invalid proxy URL '%s'", h.Proxy)
+		}
+	}
+
+	if (h.TLS.CertFile == "") != (h.TLS.KeyFile == "") {
+		return fmt.Errorf("http: tls cert_file and key_file must be set together")
+	}
+
+	switch h.TLS.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("http: unsupported tls min_version '%s' (supported: 1.2, 1.3)", h.TLS.MinVersion)
+	}
+
+	if h.MaxIdleConns < 0 || h.MaxIdleConnsPerHost < 0 || h.MaxConnsPerHost < 0 || h.IdleConnTimeout < 0 {
+		return fmt.Errorf("http: connection limits must be non-negative")
+	}
+
+	return nil
+}
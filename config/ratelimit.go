@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitConfig configures the token-bucket limits enforced by the module
+type RateLimitConfig struct {
+	// FailFast makes sends fail with a rate limit error instead of waiting for a provider token.
+	// It can be overridden per request.
+	FailFast bool `mapstructure:"fail_fast"`
+
+	// Providers maps provider names to their limits
+	Providers map[string]ProviderRateLimit `mapstructure:"providers"`
+
+	// Recipient limits how many messages a single phone number can receive
+	Recipient RecipientRateLimit `mapstructure:"recipient"`
+}
+
+// ProviderRateLimit is the token bucket of one provider
+type ProviderRateLimit struct {
+	// Rate is the number of requests per second
+	Rate float64 `mapstructure:"rate"`
+
+	// Burst is the number of requests that can be sent at once (0 means one second's worth)
+	Burst int `mapstructure:"burst"`
+}
+
+// RecipientRateLimit limits the messages sent to each recipient
+type RecipientRateLimit struct {
+	// Limit is the number of messages a recipient can receive per period (0 disables the limit)
+	Limit int `mapstructure:"limit"`
+
+	// Period is the period the limit applies to
+	Period time.Duration `mapstructure:"period"`
+}
+
+// GetRateLimits returns the rate limit configuration
+func (c *Config) GetRateLimits() RateLimitConfig {
+	return c.RateLimits
+}
+
+// GetProviderRateLimit returns the rate limit of a provider, if one is configured
+func (c *Config) GetProviderRateLimit(providerName string) (ProviderRateLimit, bool) {
+	limit, ok := c.RateLimits.Providers[providerName]
+	return limit, ok
+}
+
+// validate checks the rate limits against the configured providers
+func (r RateLimitConfig) validate(providers map[string]interface{}) error {
+	for name, limit := range r.Providers {
+		if _, ok := providers[name]; !ok {
+			return fmt.Errorf("rate_limits: provider '%s' not found in configured providers", name)
+		}
+		if limit.Rate <= 0 {
+			return fmt.Errorf("rate_limits: rate for provider '%s' must be greater than 0", name)
+		}
+		if limit.Burst < 0 {
+			return fmt.Errorf("rate_limits: burst for provider '%s' must be non-negative", name)
+		}
+	}
+
+	if r.Recipient.Limit < 0 {
+		return fmt.Errorf("rate_limits: recipient limit must be non-negative, got %d", r.Recipient.Limit)
+	}
+	if r.Recipient.Limit > 0 && r.Recipient.Period <= 0 {
+		return fmt.Errorf("rate_limits: recipient period must be greater than 0")
+	}
+
+	return nil
+}
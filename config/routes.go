@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/go-fork/sms/model"
+)
+
+// RouteRule selects the provider, and optionally the sender ID, for messages that match it.
+// A rule matches when every condition it sets matches; a rule without conditions matches
+// every message.
+type RouteRule struct {
+	// Name identifies the rule in responses and errors
+	Name string `mapstructure:"name"`
+
+	// Prefixes matches recipients whose E.164 number starts with one of the prefixes (e.g. "+8491")
+	Prefixes []string `mapstructure:"prefixes"`
+
+	// CountryCodes matches recipients in one of the country calling codes (e.g. "84")
+	CountryCodes []string `mapstructure:"country_codes"`
+
+	// Carriers matches Vietnamese recipients on one of the carriers (e.g. "viettel")
+	Carriers []string `mapstructure:"carriers"`
+
+	// By matches messages sent by one of the applications in Message.By (case-insensitive)
+	By []string `mapstructure:"by"`
+
+	// Provider is the provider that matching messages are sent through
+	Provider string `mapstructure:"provider"`
+
+	// Sender is the sender ID used for matching messages that do not set Message.From
+	Sender string `mapstructure:"sender"`
+}
+
+// Matches reports whether the rule applies to a message.
+// Recipient numbers are expected in E.164 format; the module normalizes them before matching.
+func (r *RouteRule) Matches(msg model.Message) bool {
+	number := normalizeRouteNumber(msg.To)
+	phone, err := model.ParsePhoneNumber(msg.To, "")
+	if err == nil {
+		number = phone.E164()
+	}
+
+	if len(r.Prefixes) > 0 && !matchesAny(r.Prefixes, func(prefix string) bool {
+		return strings.HasPrefix(number, normalizeRouteNumber(prefix))
+	}) {
+		return false
+	}
+
+	if len(r.CountryCodes) > 0 && !matchesAny(r.CountryCodes, func(code string) bool {
+		return strings.HasPrefix(number, "+"+strings.TrimPrefix(strings.TrimSpace(code), "+"))
+	}) {
+		return false
+	}
+
+	if len(r.Carriers) > 0 && !matchesAny(r.Carriers, func(carrier string) bool {
+		return err == nil && phone.Carrier() != model.CarrierUnknown && strings.EqualFold(carrier, string(phone.Carrier()))
+	}) {
+		return false
+	}
+
+	if len(r.By) > 0 && !matchesAny(r.By, func(by string) bool {
+		return strings.EqualFold(by, msg.By)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// GetRoutes returns the routing rules in the order they are evaluated
+func (c *Config) GetRoutes() []RouteRule {
+	return c.Routes
+}
+
+// MatchRoute returns the first routing rule that matches a message
+func (c *Config) MatchRoute(msg model.Message) (RouteRule, bool) {
+	for _, rule := range c.Routes {
+		if rule.Matches(msg) {
+			return rule, true
+		}
+	}
+
+	return RouteRule{}, false
+}
+
+// matchesAny reports whether match returns true for any of the values
+func matchesAny(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRouteNumber removes formatting characters from a phone number and
+// rewrites the international "00" prefix to "+"
+func normalizeRouteNumber(number string) string {
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, number)
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+
+	return number
+}
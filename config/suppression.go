@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// DefaultSuppressionBypassCategories are the message categories sent to suppressed numbers
+	DefaultSuppressionBypassCategories = []string{"otp"}
+
+	// DefaultOptOutKeywords are the inbound keywords that suppress the sender
+	DefaultOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT", "HUY", "HỦY"}
+
+	// DefaultOptInKeywords are the inbound keywords that lift the suppression of the sender
+	DefaultOptInKeywords = []string{"START", "UNSTOP"}
+)
+
+// SuppressionConfig configures the list of numbers that must not receive SMS messages
+type SuppressionConfig struct {
+	// Enabled turns on the suppression list, kept in memory unless File is set
+	Enabled bool `mapstructure:"enabled"`
+
+	// File is the JSON file the list is saved to; setting it enables the list
+	File string `mapstructure:"file"`
+
+	// BypassCategories are the request categories (e.g. "otp") sent even to suppressed numbers
+	// (nil means DefaultSuppressionBypassCategories)
+	BypassCategories []string `mapstructure:"bypass_categories"`
+
+	// OptOutKeywords are the inbound keywords that suppress the sender (nil means DefaultOptOutKeywords)
+	OptOutKeywords []string `mapstructure:"opt_out_keywords"`
+
+	// OptInKeywords are the inbound keywords that lift the suppression (nil means DefaultOptInKeywords)
+	OptInKeywords []string `mapstructure:"opt_in_keywords"`
+}
+
+// GetSuppression returns the suppression list configuration
+func (c *Config) GetSuppression() SuppressionConfig {
+	return c.Suppression
+}
+
+// IsEnabled reports whether a suppression list is configured
+func (s SuppressionConfig) IsEnabled() bool {
+	return s.Enabled || s.File != ""
+}
+
+// GetBypassCategories returns the categories sent to suppressed numbers
+func (s SuppressionConfig) GetBypassCategories() []string {
+	if s.BypassCategories == nil {
+		return DefaultSuppressionBypassCategories
+	}
+	return s.BypassCategories
+}
+
+// GetOptOutKeywords returns the keywords that suppress the sender
+func (s SuppressionConfig) GetOptOutKeywords() []string {
+	if s.OptOutKeywords == nil {
+		return DefaultOptOutKeywords
+	}
+	return s.OptOutKeywords
+}
+
+// GetOptInKeywords returns the keywords that lift the suppression of the sender
+func (s SuppressionConfig) GetOptInKeywords() []string {
+	if s.OptInKeywords == nil {
+		return DefaultOptInKeywords
+	}
+	return s.OptInKeywords
+}
+
+// validate checks that the keywords are single words and not both opt-out and opt-in
+func (s SuppressionConfig) validate() error {
+	optOut := make(map[string]bool)
+	for _, keyword := range s.GetOptOutKeywords() {
+		if err := validateKeyword("opt_out_keywords", keyword); err != nil {
+			return err
+		}
+		optOut[strings.ToUpper(keyword)] = true
+	}
+
+	for _, keyword := range s.GetOptInKeywords() {
+		if err := validateKeyword("opt_in_keywords", keyword); err != nil {
+			return err
+		}
+		if optOut[strings.ToUpper(keyword)] {
+			return fmt.Errorf("suppression: keyword '%s' is both an opt-out and an opt-in keyword", keyword)
+		}
+	}
+
+	for _, category := range s.GetBypassCategories() {
+		if strings.TrimSpace(category) == "" {
+			return fmt.Errorf("suppression: bypass_categories must not contain empty categories")
+		}
+	}
+
+	return nil
+}
+
+// validateKeyword checks that a keyword is a single word, as inbound keywords are the first word of a message
+func validateKeyword(field, keyword string) error {
+	if len(strings.Fields(keyword)) != 1 {
+		return fmt.Errorf("suppression: %s must be single words, got '%s'", field, keyword)
+	}
+	return nil
+}
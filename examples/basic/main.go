@@ -28,7 +28,7 @@ func main() {
 	request := model.SendSMSRequest{
 		Message: model.Message{
 			From: "SenderName",
-			To:   "+1234567890",
+			To:   "+14155552671",
 			By:   "ExampleApp",
 		},
 		Data: map[string]interface{}{
@@ -107,7 +107,7 @@ providers:
 	smsReq := model.SendSMSRequest{
 		Message: model.Message{
 			From: "Test-Sender",
-			To:   "+1234567890",
+			To:   "+14155552671",
 			By:   "ExampleApp",
 		},
 		Data: map[string]interface{}{
@@ -133,7 +133,7 @@ providers:
 	voiceReq := model.SendVoiceRequest{
 		Message: model.Message{
 			From: "VoiceBot",
-			To:   "+1234567890",
+			To:   "+14155552671",
 			By:   "ExampleApp",
 		},
 		Data: map[string]interface{}{
@@ -134,30 +134,16 @@ func sendWithDefaultProvider(ctx context.Context, module *sms.Module, phoneNumbe
 }
 
 // sendWithSpecificProvider sends an SMS using a specific provider
+// The provider is named on the request, so the module's active provider is left unchanged
 func sendWithSpecificProvider(ctx context.Context, module *sms.Module, providerName string, phoneNumber string) {
 	fmt.Printf("\n=== Example 2: Using Specific Provider (%s) ===\n", providerName)
 
-	// Store current provider to restore later
-	currentProvider, err := module.GetActiveProvider()
-	if err != nil {
-		fmt.Printf("Error getting current provider: %v\n", err)
-		return
-	}
-
-	// Switch to the specified provider
-	err = module.SwitchProvider(providerName)
-	if err != nil {
-		fmt.Printf("Failed to switch to %s provider: %v\n", providerName, err)
-		return
-	}
-
-	fmt.Printf("Switched to provider: %s\n", providerName)
-
 	request := model.SendSMSRequest{
 		Message: model.Message{
 			To: phoneNumber,
 			By: "MultiProviderExample",
 		},
+		Provider: providerName,
 		Data: map[string]interface{}{
 			"app_name": "MultiProviderExample",
 			"message":  fmt.Sprintf("This message is explicitly sent using the %s provider.", providerName),
@@ -168,9 +154,6 @@ func sendWithSpecificProvider(ctx context.Context, module *sms.Module, providerN
 	response, err := module.SendSMS(ctx, request)
 	if err != nil {
 		fmt.Printf("Failed to send SMS: %v\n", err)
-
-		// Restore original provider
-		module.SwitchProvider(currentProvider.Name())
 		return
 	}
 
@@ -178,10 +161,6 @@ func sendWithSpecificProvider(ctx context.Context, module *sms.Module, providerN
 	fmt.Printf("Message ID: %s\n", response.MessageID)
 	fmt.Printf("Provider: %s\n", response.Provider)
 	fmt.Printf("Status: %s\n", response.Status)
-
-	// Restore original provider
-	module.SwitchProvider(currentProvider.Name())
-	fmt.Printf("Restored original provider: %s\n", currentProvider.Name())
 }
 
 // demonstrateProviderSelection shows how to select providers based on message type
@@ -362,5 +341,5 @@ func demonstrateFailoverBetweenProviders(ctx context.Context, module *sms.Module
 func getPhoneNumber() string {
 	// In a real application, you would get this from user input or your database
 	// For this example, let's use a default value that you should replace
-	return "+1234567890" // Replace with an actual phone number for testing
+	return "+14155552671" // Replace with an actual phone number for testing
 }
@@ -2,22 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
-
 	"github.com/go-fork/sms"
 	"github.com/go-fork/sms/adapters/twilio"
 	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/ratelimit"
 )
 
-// This example demonstrates how to implement a rate limiter
-// to prevent exceeding provider rate limits when sending
-// large volumes of messages
+// This example demonstrates the module's built-in rate limits,
+// which prevent exceeding provider rate limits when sending
+// large volumes of messages.
+//
+// The limits are declared in the configuration file:
+//
+//	rate_limits:
+//	  providers:
+//	    twilio: {rate: 100}   # messages per second
+//	    esms: {rate: 50}
+//	    speedsms: {rate: 30}
+//	  recipient:
+//	    limit: 5              # at most 5 messages per recipient per hour
+//	    period: 1h
 
 func main() {
 	// Check if config file path is provided
@@ -46,19 +57,11 @@ func main() {
 		log.Fatalf("Failed to add Twilio provider: %v", err)
 	}
 
-	// Create a rate limiter
-	// Most SMS providers have rate limits:
-	// - Twilio: 100 messages per second
-	// - eSMS: 50 messages per second
-	// - SpeedSMS: 30 messages per second
-	// We'll use a conservative 10 messages per second here
-	limiter := rate.NewLimiter(rate.Limit(10), 1) // 10 messages per second, burst of 1
-
 	// Sample list of phone numbers to send to
 	recipients := []string{
-		"+1234567890",
-		"+2345678901",
-		"+3456789012",
+		"+14155552671",
+		"+14155552672",
+		"+14155552673",
 		// Add more recipients as needed for testing
 	}
 
@@ -78,14 +81,8 @@ func main() {
 	// Start time for statistics
 	startTime := time.Now()
 
-	// Send messages with rate limiting
+	// Send all messages at once; the module waits for a provider token before each send
 	for i, recipient := range recipients {
-		// Wait for rate limiter's permission
-		if err := limiter.Wait(context.Background()); err != nil {
-			log.Printf("Rate limiter error: %v", err)
-			continue
-		}
-
 		// Create a unique message for each recipient
 		go func(index int, phoneNumber string) {
 			defer wg.Done()
@@ -113,6 +110,11 @@ func main() {
 			mu.Lock()
 			defer mu.Unlock()
 
+			if errors.Is(err, ratelimit.ErrRateLimited) {
+				fmt.Printf("Message #%d was rate limited: %v\n", index+1, err)
+				failureCount++
+				return
+			}
 			if err != nil {
 				fmt.Printf("Failed to send message #%d: %v\n", index+1, err)
 				failureCount++
@@ -90,5 +90,5 @@ func main() {
 func getPhoneNumber() string {
 	// In a real application, you would get this from user input or your database
 	// For this example, let's use a default value that you should replace
-	return "+1234567890" // Replace with an actual phone number for testing
+	return "+14155552671" // Replace with an actual phone number for testing
 }
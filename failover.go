@@ -0,0 +1,158 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-fork/sms/model"
+)
+
+// FailoverError is returned when every provider in the failover chain has failed
+type FailoverError struct {
+	// Attempts lists each provider that was tried and why it failed
+	Attempts []model.ProviderAttempt
+}
+
+// Error returns the error message
+func (e *FailoverError) Error() string {
+	reasons := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", attempt.Provider, attempt.Error))
+	}
+	return fmt.Sprintf("all providers failed: %s", strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the errors returned by each provider
+func (e *FailoverError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		if attempt.Err != nil {
+			errs = append(errs, attempt.Err)
+		}
+	}
+	return errs
+}
+
+// providerChain returns the providers to try for a send: the named provider, or the active
+// provider when first is empty, followed by the registered failover providers in configured order.
+func (m *Module) providerChain(first string) ([]model.Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	head := m.activeProvider
+	if first != "" {
+		provider, exists := m.providers[first]
+		if !exists {
+			return nil, fmt.Errorf("provider '%s' not found", first)
+		}
+		head = provider
+	}
+
+	if head == nil {
+		return nil, fmt.Errorf("no active provider set")
+	}
+
+	chain := []model.Provider{head}
+	seen := map[string]bool{head.Name(): true}
+
+	for _, name := range m.config.Failover {
+		// Skip providers that are configured but were never registered
+		provider, exists := m.providers[name]
+		if !exists || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		chain = append(chain, provider)
+	}
+
+	return chain, nil
+}
+
+// sendChain returns the providers to try for a request.
+// A request that names a provider is sent through that provider only, without failover
+// and without changing the active provider.
+func (m *Module) sendChain(providerName string) ([]model.Provider, error) {
+	if providerName != "" {
+		provider, err := m.GetProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
+		return []model.Provider{provider}, nil
+	}
+
+	return m.providerChain("")
+}
+
+// requestError wraps an error caused by the request rather than by a provider, such as an
+// unknown template name or a strict rendering failure. Other providers would fail the same way,
+// so it stops the failover chain.
+type requestError struct {
+	err error
+}
+
+// Error returns the message of the wrapped error
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// tryProviders calls send for each provider in the chain until one succeeds.
+// It stops early when a provider rejects the recipient as invalid or opted out.
+// It returns the attempts that were made; the last attempt is the successful one.
+// A *requestError returned by send stops the chain at once: it is not recorded as an
+// attempt, and the error it wraps is returned as is.
+func tryProviders(ctx context.Context, chain []model.Provider, send func(model.Provider) error) ([]model.ProviderAttempt, error) {
+	attempts := make([]model.ProviderAttempt, 0, len(chain))
+
+	for _, provider := range chain {
+		err := send(provider)
+
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			return attempts, reqErr
+		}
+
+		attempt := model.ProviderAttempt{Provider: provider.Name(), Err: err}
+		if err != nil {
+			attempt.Error = err.Error()
+			if category := model.ErrorCategoryOf(err); category != model.ErrorCategoryUnknown {
+				attempt.Category = category
+			}
+		}
+		attempts = append(attempts, attempt)
+
+		if err == nil {
+			return attempts, nil
+		}
+
+		// The remaining providers would fail the same way once the context is done
+		// or when the recipient itself was rejected, and must not text a recipient who opted out
+		if category := model.ErrorCategoryOf(err); ctx.Err() != nil ||
+			category == model.ErrorCategoryInvalidRecipient || category == model.ErrorCategoryOptedOut {
+			break
+		}
+	}
+
+	// Keep the original error when there was nothing to fail over to
+	if len(attempts) == 1 {
+		return attempts, attempts[0].Err
+	}
+
+	return attempts, &FailoverError{Attempts: attempts}
+}
+
+// describeAttempts describes the provider calls made for a failed send, naming the number
+// of providers tried when the send failed over
+func describeAttempts(calls int, attempts []model.ProviderAttempt) string {
+	if len(attempts) > 1 {
+		return fmt.Sprintf("%d attempts across %d providers", calls, len(attempts))
+	}
+	return fmt.Sprintf("%d attempts", calls)
+}
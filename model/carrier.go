@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Carrier represents a Vietnamese mobile network operator
+type Carrier string
+
+const (
+	// CarrierUnknown is returned for non-Vietnamese numbers and unknown prefixes
+	CarrierUnknown Carrier = ""
+
+	// CarrierViettel is Viettel Telecom
+	CarrierViettel Carrier = "viettel"
+
+	// CarrierMobifone is MobiFone
+	CarrierMobifone Carrier = "mobifone"
+
+	// CarrierVinaphone is VinaPhone
+	CarrierVinaphone Carrier = "vinaphone"
+
+	// CarrierVietnamobile is Vietnamobile
+	CarrierVietnamobile Carrier = "vietnamobile"
+
+	// CarrierGmobile is Gmobile
+	CarrierGmobile Carrier = "gmobile"
+
+	// CarrierItel is the Itel virtual network
+	CarrierItel Carrier = "itel"
+
+	// CarrierReddi is the Reddi virtual network
+	CarrierReddi Carrier = "reddi"
+)
+
+// vietnamCallingCode is the country calling code of Vietnam
+const vietnamCallingCode = "84"
+
+var (
+	// carrierPrefixesMu guards carrierPrefixes
+	carrierPrefixesMu sync.RWMutex
+
+	// carrierPrefixes maps the leading digits of Vietnamese mobile national numbers to carriers
+	carrierPrefixes = map[string]Carrier{
+		"32": CarrierViettel, "33": CarrierViettel, "34": CarrierViettel, "35": CarrierViettel,
+		"36": CarrierViettel, "37": CarrierViettel, "38": CarrierViettel, "39": CarrierViettel,
+		"86": CarrierViettel, "96": CarrierViettel, "97": CarrierViettel, "98": CarrierViettel,
+
+		"70": CarrierMobifone, "76": CarrierMobifone, "77": CarrierMobifone, "78": CarrierMobifone,
+		"79": CarrierMobifone, "89": CarrierMobifone, "90": CarrierMobifone, "93": CarrierMobifone,
+
+		"81": CarrierVinaphone, "82": CarrierVinaphone, "83": CarrierVinaphone, "84": CarrierVinaphone,
+		"85": CarrierVinaphone, "88": CarrierVinaphone, "91": CarrierVinaphone, "94": CarrierVinaphone,
+
+		"52": CarrierVietnamobile, "56": CarrierVietnamobile, "58": CarrierVietnamobile, "92": CarrierVietnamobile,
+
+		"59": CarrierGmobile, "99": CarrierGmobile,
+
+		"87": CarrierItel,
+
+		"55": CarrierReddi,
+	}
+)
+
+// IsKnownCarrier reports whether a carrier has at least one registered prefix
+func IsKnownCarrier(carrier Carrier) bool {
+	carrierPrefixesMu.RLock()
+	defer carrierPrefixesMu.RUnlock()
+
+	for _, c := range carrierPrefixes {
+		if c == carrier {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterCarrierPrefix assigns the Vietnamese mobile numbers starting with prefix to a carrier.
+// prefix is the beginning of the national number without the leading 0 (e.g. "96" or "522");
+// the longest registered prefix wins. Registering CarrierUnknown removes the prefix.
+func RegisterCarrierPrefix(prefix string, carrier Carrier) error {
+	if prefix == "" || strings.Trim(prefix, "0123456789") != "" {
+		return fmt.Errorf("invalid carrier prefix '%s'", prefix)
+	}
+
+	carrierPrefixesMu.Lock()
+	defer carrierPrefixesMu.Unlock()
+
+	if carrier == CarrierUnknown {
+		delete(carrierPrefixes, prefix)
+		return nil
+	}
+
+	carrierPrefixes[prefix] = carrier
+	return nil
+}
+
+// DetectCarrier returns the carrier of a Vietnamese mobile number from its prefix.
+// The number may be written in any format ParsePhoneNumber accepts for region "VN".
+// Numbers that were ported to another carrier keep the carrier of their original prefix.
+func DetectCarrier(number string) Carrier {
+	phone, err := ParsePhoneNumber(number, "VN")
+	if err != nil {
+		return CarrierUnknown
+	}
+	return phone.Carrier()
+}
+
+// Carrier returns the Vietnamese mobile carrier of the number, or CarrierUnknown
+func (p PhoneNumber) Carrier() Carrier {
+	if p.CountryCode != vietnamCallingCode {
+		return CarrierUnknown
+	}
+
+	carrierPrefixesMu.RLock()
+	defer carrierPrefixesMu.RUnlock()
+
+	for length := len(p.NationalNumber); length > 0; length-- {
+		if carrier, ok := carrierPrefixes[p.NationalNumber[:length]]; ok {
+			return carrier
+		}
+	}
+
+	return CarrierUnknown
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DeliveryEvent is a delivery report pushed by a provider to a webhook, normalized across providers
+type DeliveryEvent struct {
+	// Provider is the name of the provider that sent the report
+	Provider string `json:"provider"`
+
+	// MessageID is the identifier the provider assigned to the message when it was sent
+	MessageID string `json:"message_id"`
+
+	// Status is the new normalized delivery status (pending, sent, delivered, failed, unknown)
+	Status MessageStatus `json:"status"`
+
+	// RawStatus is the status exactly as the provider reported it
+	RawStatus string `json:"raw_status"`
+
+	// ErrorCode is the provider error code when delivery failed (if any)
+	ErrorCode string `json:"error_code,omitempty"`
+
+	// Recipient is the phone number the message was sent to (if reported)
+	Recipient string `json:"recipient,omitempty"`
+
+	// Timestamp is when the status changed, or when the report was received
+	// if the provider does not say
+	Timestamp time.Time `json:"timestamp"`
+
+	// Payload contains the raw fields of the report
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// String returns a string representation of the DeliveryEvent
+func (e *DeliveryEvent) String() string {
+	return fmt.Sprintf("Delivery report [%s] via %s: %s (%s)", e.MessageID, e.Provider, e.Status, e.RawStatus)
+}
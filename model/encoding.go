@@ -0,0 +1,148 @@
+package model
+
+import (
+	"unicode/utf16"
+)
+
+// Encoding represents the character encoding an SMS is sent with
+type Encoding string
+
+const (
+	// EncodingGSM7 is the GSM 03.38 7-bit default alphabet
+	EncodingGSM7 Encoding = "GSM-7"
+
+	// EncodingUCS2 is the 16-bit encoding used when a message has characters outside GSM-7
+	EncodingUCS2 Encoding = "UCS-2"
+)
+
+const (
+	// GSM7SingleSegmentLength is the number of septets in a single GSM-7 message
+	GSM7SingleSegmentLength = 160
+
+	// GSM7MultiSegmentLength is the number of septets per part of a concatenated GSM-7 message
+	GSM7MultiSegmentLength = 153
+
+	// UCS2SingleSegmentLength is the number of UTF-16 code units in a single UCS-2 message
+	UCS2SingleSegmentLength = 70
+
+	// UCS2MultiSegmentLength is the number of UTF-16 code units per part of a concatenated UCS-2 message
+	UCS2MultiSegmentLength = 67
+)
+
+// gsm7Basic is the GSM 03.38 basic character set; each character takes one septet
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension is the GSM 03.38 extension table; each character takes two septets
+const gsm7Extension = "\f^{}\\[~]|€"
+
+var (
+	// gsm7BasicSet and gsm7ExtensionSet index the GSM-7 character sets
+	gsm7BasicSet     = runeSet(gsm7Basic)
+	gsm7ExtensionSet = runeSet(gsm7Extension)
+)
+
+// SegmentInfo describes how an SMS body is encoded and split into parts
+type SegmentInfo struct {
+	// Encoding is the encoding the body requires
+	Encoding Encoding `json:"encoding"`
+
+	// Characters is the number of characters in the body
+	Characters int `json:"characters"`
+
+	// Units is the encoded length: septets for GSM-7, UTF-16 code units for UCS-2
+	Units int `json:"units"`
+
+	// Segments is the number of SMS parts needed to send the body
+	Segments int `json:"segments"`
+
+	// UnicodeCharacters lists the distinct characters that forced UCS-2, in order of appearance
+	UnicodeCharacters []string `json:"unicode_characters,omitempty"`
+}
+
+// AnalyzeSMS reports the encoding, length and number of segments of an SMS body
+func AnalyzeSMS(body string) SegmentInfo {
+	info := SegmentInfo{Encoding: EncodingGSM7}
+
+	seen := make(map[rune]bool)
+	for _, r := range body {
+		info.Characters++
+		if !IsGSM7Rune(r) && !seen[r] {
+			seen[r] = true
+			info.UnicodeCharacters = append(info.UnicodeCharacters, string(r))
+		}
+	}
+
+	if len(info.UnicodeCharacters) > 0 {
+		info.Encoding = EncodingUCS2
+	}
+
+	info.Units, info.Segments = countSegments(body, info.Encoding)
+	return info
+}
+
+// IsGSM7 reports whether a body can be sent with the GSM-7 alphabet
+func IsGSM7(body string) bool {
+	for _, r := range body {
+		if !IsGSM7Rune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsGSM7Rune reports whether a character is in the GSM-7 basic or extension table
+func IsGSM7Rune(r rune) bool {
+	return gsm7BasicSet[r] || gsm7ExtensionSet[r]
+}
+
+// countSegments returns the encoded length of a body and the number of parts it needs.
+// Characters that take two units (GSM-7 extension characters, UTF-16 surrogate pairs)
+// are never split across parts.
+func countSegments(body string, encoding Encoding) (int, int) {
+	single, multi := GSM7SingleSegmentLength, GSM7MultiSegmentLength
+	if encoding == EncodingUCS2 {
+		single, multi = UCS2SingleSegmentLength, UCS2MultiSegmentLength
+	}
+
+	var total int
+	var sizes []int
+	for _, r := range body {
+		size := 1
+		switch {
+		case encoding == EncodingGSM7 && gsm7ExtensionSet[r]:
+			size = 2
+		case encoding == EncodingUCS2 && utf16.RuneLen(r) == 2:
+			size = 2
+		}
+		total += size
+		sizes = append(sizes, size)
+	}
+
+	if total == 0 {
+		return 0, 0
+	}
+	if total <= single {
+		return total, 1
+	}
+
+	segments, used := 1, 0
+	for _, size := range sizes {
+		if used+size > multi {
+			segments++
+			used = 0
+		}
+		used += size
+	}
+
+	return total, segments
+}
+
+// runeSet builds a lookup set from the characters of s
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrorCategory is the normalized reason a provider rejected a request
+type ErrorCategory string
+
+const (
+	// ErrorCategoryAuth indicates invalid credentials or a suspended account
+	ErrorCategoryAuth ErrorCategory = "auth"
+
+	// ErrorCategoryInsufficientBalance indicates the account has no credit left
+	ErrorCategoryInsufficientBalance ErrorCategory = "insufficient_balance"
+
+	// ErrorCategoryInvalidRecipient indicates the recipient number cannot receive messages
+	ErrorCategoryInvalidRecipient ErrorCategory = "invalid_recipient"
+
+	// ErrorCategoryOptedOut indicates the recipient unsubscribed from messages sent by the account or sender
+	ErrorCategoryOptedOut ErrorCategory = "opted_out"
+
+	// ErrorCategoryRateLimited indicates the provider is throttling requests
+	ErrorCategoryRateLimited ErrorCategory = "rate_limited"
+
+	// ErrorCategoryContentRejected indicates the message content or sender was refused
+	ErrorCategoryContentRejected ErrorCategory = "content_rejected"
+
+	// ErrorCategoryTransient indicates a temporary provider failure that may succeed on retry
+	ErrorCategoryTransient ErrorCategory = "transient"
+
+	// ErrorCategoryInvalidRequest indicates missing or invalid request parameters
+	ErrorCategoryInvalidRequest ErrorCategory = "invalid_request"
+
+	// ErrorCategoryUnknown is used when the provider error could not be classified
+	ErrorCategoryUnknown ErrorCategory = "unknown"
+)
+
+// ProviderError is returned by adapters when a provider rejects a request
+type ProviderError struct {
+	// Provider is the name of the provider that returned the error
+	Provider string
+
+	// Code is the raw provider error code (Twilio error_code, eSMS CodeResult, SpeedSMS code)
+	Code string
+
+	// HTTPStatus is the HTTP status code of the provider response (0 if not applicable)
+	HTTPStatus int
+
+	// Category is the normalized classification of the error
+	Category ErrorCategory
+
+	// Message is the error message returned by the provider
+	Message string
+
+	// RetryAfter is the delay the provider asked for before the next attempt (0 if none)
+	RetryAfter time.Duration
+
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error returns the error message
+func (e *ProviderError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	b.WriteString(" error")
+	if e.Code != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Code)
+	}
+	if e.HTTPStatus != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", e.HTTPStatus)
+	}
+	fmt.Fprintf(&b, " [%s]", e.Category)
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retriable reports whether the request may succeed if it is sent again
+func (e *ProviderError) Retriable() bool {
+	return e.Category == ErrorCategoryTransient || e.Category == ErrorCategoryRateLimited
+}
+
+// RetryDelay returns the delay the provider asked for before the next attempt
+func (e *ProviderError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// ErrorCategoryOf returns the category of the first ProviderError in err's chain,
+// or ErrorCategoryUnknown if there is none
+func ErrorCategoryOf(err error) ErrorCategory {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Category
+	}
+	return ErrorCategoryUnknown
+}
+
+// CategoryForHTTPStatus classifies an HTTP error status for providers whose
+// error codes do not identify the problem
+func CategoryForHTTPStatus(status int) ErrorCategory {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorCategoryAuth
+	case status == http.StatusPaymentRequired:
+		return ErrorCategoryInsufficientBalance
+	case status == http.StatusTooManyRequests:
+		return ErrorCategoryRateLimited
+	case status == http.StatusRequestTimeout || status >= 500:
+		return ErrorCategoryTransient
+	case status >= 400:
+		return ErrorCategoryInvalidRequest
+	default:
+		return ErrorCategoryUnknown
+	}
+}
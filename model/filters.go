@@ -0,0 +1,231 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// TemplateFilter transforms a placeholder value in a template such as {name|upper}.
+// arg is the text after the filter name's colon (e.g. "VND" in {amount|currency:VND}),
+// with surrounding quotes removed.
+type TemplateFilter func(value interface{}, arg string) (interface{}, error)
+
+// DefaultTimeLayout is the layout used by the time filter when none is given
+const DefaultTimeLayout = "15:04 02/01/2006"
+
+var (
+	// filtersMu guards templateFilters
+	filtersMu sync.RWMutex
+
+	// templateFilters holds the filters available to templates by name
+	templateFilters = map[string]TemplateFilter{
+		"upper":    filterUpper,
+		"lower":    filterLower,
+		"title":    filterTitle,
+		"trim":     filterTrim,
+		"number":   filterNumber,
+		"currency": filterCurrency,
+		"time":     filterTime,
+	}
+
+	// currencyFormats describes how amounts are written for known currencies
+	currencyFormats = map[string]struct {
+		decimals  int
+		thousands string
+		decimal   string
+	}{
+		"VND": {decimals: 0, thousands: ".", decimal: ","},
+		"JPY": {decimals: 0, thousands: ",", decimal: "."},
+		"KRW": {decimals: 0, thousands: ",", decimal: "."},
+		"EUR": {decimals: 2, thousands: ".", decimal: ","},
+	}
+)
+
+// RegisterTemplateFilter makes a custom filter available to all templates.
+// Registering a filter with the name of an existing one replaces it.
+// "default" is reserved and cannot be registered.
+func RegisterTemplateFilter(name string, filter TemplateFilter) error {
+	if name == "" || name == "default" || filter == nil {
+		return fmt.Errorf("invalid template filter '%s'", name)
+	}
+
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+
+	templateFilters[name] = filter
+	return nil
+}
+
+// lookupTemplateFilter returns the filter registered under name
+func lookupTemplateFilter(name string) (TemplateFilter, bool) {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+
+	filter, ok := templateFilters[name]
+	return filter, ok
+}
+
+// filterUpper converts a value to upper case: {name|upper}
+func filterUpper(value interface{}, _ string) (interface{}, error) {
+	return strings.ToUpper(fmt.Sprintf("%v", value)), nil
+}
+
+// filterLower converts a value to lower case: {name|lower}
+func filterLower(value interface{}, _ string) (interface{}, error) {
+	return strings.ToLower(fmt.Sprintf("%v", value)), nil
+}
+
+// filterTitle capitalizes the first letter of each word: {name|title}
+func filterTitle(value interface{}, _ string) (interface{}, error) {
+	runes := []rune(fmt.Sprintf("%v", value))
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes), nil
+}
+
+// filterTrim removes leading and trailing whitespace: {name|trim}
+func filterTrim(value interface{}, _ string) (interface{}, error) {
+	return strings.TrimSpace(fmt.Sprintf("%v", value)), nil
+}
+
+// filterNumber formats a number with thousands separators: {count|number} or {ratio|number:2}
+func filterNumber(value interface{}, arg string) (interface{}, error) {
+	number, err := toFloat(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := 0
+	if arg != "" {
+		if decimals, err = strconv.Atoi(arg); err != nil || decimals < 0 {
+			return nil, fmt.Errorf("invalid number of decimals '%s'", arg)
+		}
+	}
+
+	return formatNumber(number, decimals, ",", "."), nil
+}
+
+// filterCurrency formats an amount for a currency: {amount|currency:VND} renders "1.500.000 VND"
+func filterCurrency(value interface{}, arg string) (interface{}, error) {
+	amount, err := toFloat(value)
+	if err != nil {
+		return nil, err
+	}
+
+	code := strings.ToUpper(arg)
+	if code == "" {
+		return nil, fmt.Errorf("currency code is required")
+	}
+
+	format, ok := currencyFormats[code]
+	if !ok {
+		format.decimals, format.thousands, format.decimal = 2, ",", "."
+	}
+
+	return formatNumber(amount, format.decimals, format.thousands, format.decimal) + " " + code, nil
+}
+
+// filterTime formats a time with a Go layout: {expires_at|time:15:04}.
+// Accepts time.Time, RFC 3339 strings and Unix timestamps in seconds.
+func filterTime(value interface{}, arg string) (interface{}, error) {
+	layout := arg
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case *time.Time:
+		if v == nil {
+			return nil, fmt.Errorf("nil time")
+		}
+		return v.Format(layout), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time '%s': %w", v, err)
+		}
+		return t.Format(layout), nil
+	}
+
+	seconds, err := toFloat(value)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported time value %v", value)
+	}
+	return time.Unix(int64(seconds), 0).Format(layout), nil
+}
+
+// toFloat converts numeric values and numeric strings to float64
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", v)
+		}
+		return number, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+// formatNumber writes a number with the given decimals and separators
+func formatNumber(number float64, decimals int, thousands, decimal string) string {
+	text := strconv.FormatFloat(math.Abs(number), 'f', decimals, 64)
+	whole, fraction, _ := strings.Cut(text, ".")
+
+	var b strings.Builder
+	if number < 0 && text != strconv.FormatFloat(0, 'f', decimals, 64) {
+		b.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(decimal)
+		b.WriteString(fraction)
+	}
+
+	return b.String()
+}
+
+// isTruthy reports whether a section condition is met: the value exists and is not empty,
+// zero or false
+func isTruthy(value interface{}, exists bool) bool {
+	if !exists || value == nil {
+		return false
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+
+	return !rv.IsZero()
+}
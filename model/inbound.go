@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// InboundMessage is an SMS sent by a user to one of the application's numbers (mobile originated),
+// normalized across providers
+type InboundMessage struct {
+	// Provider is the name of the provider that received the message
+	Provider string `json:"provider"`
+
+	// MessageID is the identifier the provider assigned to the message (if any)
+	MessageID string `json:"message_id,omitempty"`
+
+	// From is the phone number of the sender
+	From string `json:"from"`
+
+	// To is the number or short code the message was sent to
+	To string `json:"to,omitempty"`
+
+	// Body is the text of the message
+	Body string `json:"body"`
+
+	// ReceivedAt is when the provider received the message, or when the webhook
+	// received it if the provider does not say
+	ReceivedAt time.Time `json:"received_at"`
+
+	// Payload contains the raw fields of the callback
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// Keyword returns the first word of the body in upper case, without surrounding punctuation,
+// so that "stop.", " Stop please" and "STOP" all yield "STOP"
+func (m *InboundMessage) Keyword() string {
+	fields := strings.Fields(m.Body)
+	if len(fields) == 0 {
+		return ""
+	}
+	word := strings.TrimFunc(fields[0], func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	return strings.ToUpper(word)
+}
+
+// String returns a string representation of the InboundMessage
+func (m *InboundMessage) String() string {
+	return fmt.Sprintf("Inbound SMS via %s from %s to %s: %s", m.Provider, m.From, m.To, m.Body)
+}
@@ -19,51 +19,59 @@ type Message struct {
 }
 
 // Render processes a template string with provided data to generate message content
-// It replaces placeholders in the format {key} with corresponding values from data
+// It replaces placeholders in the format {key} with corresponding values from data.
+// Placeholders may apply filters ({name|upper}, {amount|currency:VND}, {name|default:"customer"})
+// and {?key}...{/key} sections are only rendered when key has a non-empty value.
+// Placeholders that cannot be rendered are left unchanged; use "{{" and "}}" for literal braces.
 func (m *Message) Render(template string, data map[string]interface{}) string {
-	// If no template is provided, return empty string
-	if template == "" {
-		return ""
-	}
+	result, _, _ := renderTemplate(template, m.lookup(data))
+	return result
+}
 
-	// Add message fields to the data map if not already present
-	if data == nil {
-		data = make(map[string]interface{})
+// RenderStrict works like Render but returns an error instead of leaving placeholders
+// in the output: a *MissingVariablesError listing every placeholder that has no value,
+// or the errors of filters that could not be applied
+func (m *Message) RenderStrict(template string, data map[string]interface{}) (string, error) {
+	if err := ValidateTemplate(template); err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
 	}
 
-	// Add message fields to data if not explicitly provided
-	if _, exists := data["from"]; !exists {
-		data["from"] = m.From
-	}
-	if _, exists := data["to"]; !exists {
-		data["to"] = m.To
-	}
-	if _, exists := data["by"]; !exists {
-		data["by"] = m.By
+	result, missing, err := renderTemplate(template, m.lookup(data))
+	if err != nil {
+		return "", err
 	}
 
-	// Regular expression to find placeholders like {key}
-	re := regexp.MustCompile(`{([^{}]+)}`)
+	if len(missing) > 0 {
+		return "", &MissingVariablesError{Variables: missing}
+	}
 
-	// Replace all placeholders with their values
-	result := re.ReplaceAllStringFunc(template, func(placeholder string) string {
-		// Extract the key from the placeholder (removing { and })
-		key := placeholder[1 : len(placeholder)-1]
+	return result, nil
+}
 
-		// Check if the key exists in the data map
+// lookup returns a function that resolves template keys from data,
+// falling back to the message fields {from}, {to} and {by}
+func (m *Message) lookup(data map[string]interface{}) func(string) (interface{}, bool) {
+	return func(key string) (interface{}, bool) {
 		if value, exists := data[key]; exists {
-			return fmt.Sprintf("%v", value)
+			return value, true
 		}
 
-		// Return the original placeholder if the key doesn't exist
-		return placeholder
-	})
+		switch key {
+		case "from":
+			return m.From, true
+		case "to":
+			return m.To, true
+		case "by":
+			return m.By, true
+		}
 
-	return result
+		return nil, false
+	}
 }
 
 // ValidatePhoneNumber performs basic validation on a phone number
 // Returns true if the phone number appears to be valid
+// Use ParsePhoneNumber to normalize a number to E.164 and check its length for the country
 func ValidatePhoneNumber(phoneNumber string) bool {
 	// Basic validation for demonstration
 	// In a production environment, consider using a dedicated phone number validation library
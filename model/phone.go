@@ -0,0 +1,212 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidPhoneNumber indicates that a phone number cannot be parsed or has an impossible length
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// maxE164Digits is the maximum number of digits in an E.164 number, country code included
+const maxE164Digits = 15
+
+// PhoneNumber is a phone number split into its E.164 parts
+type PhoneNumber struct {
+	// CountryCode is the country calling code without "+" (e.g. "84")
+	CountryCode string `json:"country_code"`
+
+	// NationalNumber is the number within the country, without trunk prefix (e.g. "912345678")
+	NationalNumber string `json:"national_number"`
+}
+
+// E164 returns the number in E.164 format (e.g. "+84912345678")
+func (p PhoneNumber) E164() string {
+	return "+" + p.CountryCode + p.NationalNumber
+}
+
+// String returns the number in E.164 format
+func (p PhoneNumber) String() string {
+	return p.E164()
+}
+
+// phoneRegion describes how numbers are written in a region
+type phoneRegion struct {
+	// code is the country calling code
+	code string
+
+	// trunk is the prefix dialled before national numbers within the country (e.g. "0")
+	trunk string
+
+	// minLength and maxLength bound the length of national numbers
+	minLength, maxLength int
+}
+
+// phoneRegions maps ISO 3166-1 alpha-2 region codes to their numbering rules
+var phoneRegions = map[string]phoneRegion{
+	"VN": {code: "84", trunk: "0", minLength: 9, maxLength: 10},
+	"US": {code: "1", trunk: "1", minLength: 10, maxLength: 10},
+	"CA": {code: "1", trunk: "1", minLength: 10, maxLength: 10},
+	"GB": {code: "44", trunk: "0", minLength: 9, maxLength: 10},
+	"AU": {code: "61", trunk: "0", minLength: 9, maxLength: 9},
+	"FR": {code: "33", trunk: "0", minLength: 9, maxLength: 9},
+	"DE": {code: "49", trunk: "0", minLength: 6, maxLength: 13},
+	"IN": {code: "91", trunk: "0", minLength: 10, maxLength: 10},
+	"CN": {code: "86", trunk: "0", minLength: 9, maxLength: 11},
+	"JP": {code: "81", trunk: "0", minLength: 9, maxLength: 10},
+	"KR": {code: "82", trunk: "0", minLength: 8, maxLength: 10},
+	"TW": {code: "886", trunk: "0", minLength: 8, maxLength: 9},
+	"HK": {code: "852", minLength: 8, maxLength: 8},
+	"SG": {code: "65", minLength: 8, maxLength: 8},
+	"MY": {code: "60", trunk: "0", minLength: 8, maxLength: 10},
+	"TH": {code: "66", trunk: "0", minLength: 8, maxLength: 9},
+	"ID": {code: "62", trunk: "0", minLength: 8, maxLength: 12},
+	"PH": {code: "63", trunk: "0", minLength: 8, maxLength: 10},
+	"KH": {code: "855", trunk: "0", minLength: 8, maxLength: 9},
+	"LA": {code: "856", trunk: "0", minLength: 8, maxLength: 10},
+}
+
+// twoDigitCallingCodes lists the two-digit country calling codes.
+// Calling codes are prefix-free: "1" and "7" are the only one-digit codes,
+// and every other code not listed here has three digits.
+var twoDigitCallingCodes = map[string]bool{
+	"20": true, "27": true, "30": true, "31": true, "32": true, "33": true, "34": true, "36": true,
+	"39": true, "40": true, "41": true, "43": true, "44": true, "45": true, "46": true, "47": true,
+	"48": true, "49": true, "51": true, "52": true, "53": true, "54": true, "55": true, "56": true,
+	"57": true, "58": true, "60": true, "61": true, "62": true, "63": true, "64": true, "65": true,
+	"66": true, "81": true, "82": true, "84": true, "86": true, "90": true, "91": true, "92": true,
+	"93": true, "94": true, "95": true, "98": true,
+}
+
+// IsSupportedRegion reports whether national numbers can be parsed for a region code (e.g. "VN")
+func IsSupportedRegion(region string) bool {
+	_, ok := phoneRegions[strings.ToUpper(region)]
+	return ok
+}
+
+// SupportedRegions returns the sorted region codes that national numbers can be parsed for
+func SupportedRegions() []string {
+	regions := make([]string, 0, len(phoneRegions))
+	for region := range phoneRegions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// ParsePhoneNumber parses a phone number and normalizes it to E.164.
+// Numbers starting with "+" or "00" are international; other numbers are read in the
+// national format of defaultRegion, so "0912345678" with region "VN" becomes "+84912345678".
+// A trunk prefix written after the country code is dropped ("+84 0912345678" is "+84912345678").
+// Spaces, dots, hyphens and parentheses are ignored. The national number length is checked
+// against the rules of the country when they are known.
+func ParsePhoneNumber(number, defaultRegion string) (PhoneNumber, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(number))
+
+	international := false
+	switch {
+	case strings.HasPrefix(cleaned, "+"):
+		cleaned, international = cleaned[1:], true
+	case strings.HasPrefix(cleaned, "00"):
+		cleaned, international = cleaned[2:], true
+	}
+
+	if cleaned == "" || strings.Trim(cleaned, "0123456789") != "" {
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' must contain only digits", ErrInvalidPhoneNumber, number)
+	}
+
+	if international {
+		return parseInternational(number, cleaned)
+	}
+
+	if defaultRegion == "" {
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' has no country code and no default region is set",
+			ErrInvalidPhoneNumber, number)
+	}
+
+	region, ok := phoneRegions[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return PhoneNumber{}, fmt.Errorf("%w: unsupported region '%s'", ErrInvalidPhoneNumber, defaultRegion)
+	}
+
+	// Try the number with the trunk prefix removed, as written, and finally as written with
+	// the country code but without "+" (e.g. "84912345678")
+	candidates := []string{cleaned}
+	if region.trunk != "" && strings.HasPrefix(cleaned, region.trunk) {
+		candidates = append([]string{cleaned[len(region.trunk):]}, candidates...)
+	}
+	if strings.HasPrefix(cleaned, region.code) {
+		candidates = append(candidates, cleaned[len(region.code):])
+	}
+
+	for _, national := range candidates {
+		if validNationalLength(region, national) {
+			return PhoneNumber{CountryCode: region.code, NationalNumber: national}, nil
+		}
+	}
+
+	return PhoneNumber{}, fmt.Errorf("%w: '%s' has an invalid length for region %s",
+		ErrInvalidPhoneNumber, number, strings.ToUpper(defaultRegion))
+}
+
+// parseInternational splits the digits of an international number into country code and national number
+func parseInternational(number, digits string) (PhoneNumber, error) {
+	if len(digits) > maxE164Digits {
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' has more than %d digits", ErrInvalidPhoneNumber, number, maxE164Digits)
+	}
+
+	codeLength := 3
+	switch {
+	case digits[0] == '0':
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' has no valid country code", ErrInvalidPhoneNumber, number)
+	case digits[0] == '1' || digits[0] == '7':
+		codeLength = 1
+	case len(digits) >= 2 && twoDigitCallingCodes[digits[:2]]:
+		codeLength = 2
+	}
+
+	if len(digits) <= codeLength {
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' is too short", ErrInvalidPhoneNumber, number)
+	}
+
+	phone := PhoneNumber{CountryCode: digits[:codeLength], NationalNumber: digits[codeLength:]}
+
+	valid := len(phone.NationalNumber) >= 4
+	if region, ok := regionForCode(phone.CountryCode); ok {
+		// Drop a trunk prefix written after the country code (e.g. "+84 0912345678");
+		// national numbers never start with it
+		if region.trunk != "" {
+			phone.NationalNumber = strings.TrimPrefix(phone.NationalNumber, region.trunk)
+		}
+		valid = validNationalLength(region, phone.NationalNumber)
+	}
+	if !valid {
+		return PhoneNumber{}, fmt.Errorf("%w: '%s' has an invalid length for country code +%s",
+			ErrInvalidPhoneNumber, number, phone.CountryCode)
+	}
+
+	return phone, nil
+}
+
+// regionForCode returns the numbering rules for a country calling code, if known
+func regionForCode(code string) (phoneRegion, bool) {
+	for _, region := range phoneRegions {
+		if region.code == code {
+			return region, true
+		}
+	}
+	return phoneRegion{}, false
+}
+
+// validNationalLength reports whether a national number has a possible length for a region
+func validNationalLength(region phoneRegion, national string) bool {
+	return len(national) >= region.minLength && len(national) <= region.maxLength
+}
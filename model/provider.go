@@ -15,3 +15,12 @@ type Provider interface {
 	// SendVoiceCall initiates a voice call through the provider
 	SendVoiceCall(ctx context.Context, request SendVoiceRequest) (SendVoiceResponse, error)
 }
+
+// StatusProvider is implemented by providers that can look up the delivery status of a sent message.
+// It is optional; Module.GetMessageStatus reports providers without it as unsupported.
+type StatusProvider interface {
+	Provider
+
+	// GetMessageStatus returns the current status of the message with the given provider message ID
+	GetMessageStatus(ctx context.Context, messageID string) (MessageStatusResponse, error)
+}
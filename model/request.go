@@ -2,18 +2,72 @@ package model
 
 import "fmt"
 
+// DefaultTemplate is the template providers render when a request has neither a body nor a template
+const DefaultTemplate = "{message}"
+
+// MessageCategory classifies the purpose of a message, e.g. to let one-time passwords
+// reach recipients who opted out of other messages
+type MessageCategory string
+
+const (
+	// CategoryOTP is used for one-time passwords and verification codes
+	CategoryOTP MessageCategory = "otp"
+
+	// CategoryTransactional is used for messages the recipient's own actions trigger (receipts, alerts)
+	CategoryTransactional MessageCategory = "transactional"
+
+	// CategoryMarketing is used for promotional messages
+	CategoryMarketing MessageCategory = "marketing"
+)
+
 // SendSMSRequest represents a request to send an SMS
 type SendSMSRequest struct {
 	// Message contains the core message information (From, To, By)
 	Message Message `json:"message"`
 
+	// Region is the region (e.g. "VN") used to read a recipient number written in national format
+	// If empty, the default region from configuration will be used
+	Region string `json:"region,omitempty"`
+
+	// Provider optionally names the registered provider to send this request through
+	// If empty, the active provider and the configured failover chain are used
+	Provider string `json:"provider,omitempty"`
+
+	// FailFast overrides the configured rate_limits.fail_fast setting for this request
+	// When true, a provider without a free rate limit token is skipped instead of waited for
+	FailFast *bool `json:"fail_fast,omitempty"`
+
+	// Category classifies the message (otp, transactional, marketing, ...)
+	// Categories listed in suppression.bypass_categories are sent even to suppressed numbers
+	Category MessageCategory `json:"category,omitempty"`
+
 	// Template is an optional message template to use
 	// If empty, the default template from configuration will be used
 	Template string `json:"template,omitempty"`
 
+	// TemplateName is an optional name of a template from the configured template registry
+	// It is used when Template is empty
+	TemplateName string `json:"template_name,omitempty"`
+
+	// Locale selects the TemplateName variant (e.g. "vi", "en-US")
+	// If empty, the default locale from configuration will be used
+	Locale string `json:"locale,omitempty"`
+
+	// Strict makes rendering fail when a placeholder has no value instead of sending it as-is
+	// Strict rendering can also be enabled for all requests in configuration
+	Strict bool `json:"strict,omitempty"`
+
+	// Transliterate overrides the configured transliteration setting for this request
+	// When true, the body is rewritten to GSM-7 (e.g. Vietnamese diacritics are stripped)
+	Transliterate *bool `json:"transliterate,omitempty"`
+
 	// Data contains variables to bind into the template
 	Data map[string]interface{} `json:"data,omitempty"`
 
+	// Body is the rendered message content
+	// It is filled in by the module; when set, providers send it as-is instead of rendering Template
+	Body string `json:"body,omitempty"`
+
 	// Options contains provider-specific options
 	Options map[string]interface{} `json:"options,omitempty"`
 }
@@ -23,13 +77,41 @@ type SendVoiceRequest struct {
 	// Message contains the core message information (From, To, By)
 	Message Message `json:"message"`
 
+	// Region is the region (e.g. "VN") used to read a recipient number written in national format
+	// If empty, the default region from configuration will be used
+	Region string `json:"region,omitempty"`
+
+	// Provider optionally names the registered provider to make this call through
+	// If empty, the active provider and the configured failover chain are used
+	Provider string `json:"provider,omitempty"`
+
+	// FailFast overrides the configured rate_limits.fail_fast setting for this request
+	// When true, a provider without a free rate limit token is skipped instead of waited for
+	FailFast *bool `json:"fail_fast,omitempty"`
+
 	// Template is an optional voice script template to use
 	// If empty, the default template from configuration will be used
 	Template string `json:"template,omitempty"`
 
+	// TemplateName is an optional name of a template from the configured template registry
+	// It is used when Template is empty
+	TemplateName string `json:"template_name,omitempty"`
+
+	// Locale selects the TemplateName variant (e.g. "vi", "en-US")
+	// If empty, the default locale from configuration will be used
+	Locale string `json:"locale,omitempty"`
+
+	// Strict makes rendering fail when a placeholder has no value instead of sending it as-is
+	// Strict rendering can also be enabled for all requests in configuration
+	Strict bool `json:"strict,omitempty"`
+
 	// Data contains variables to bind into the template
 	Data map[string]interface{} `json:"data,omitempty"`
 
+	// Body is the rendered voice script
+	// It is filled in by the module; when set, providers use it as-is instead of rendering Template
+	Body string `json:"body,omitempty"`
+
 	// Options contains provider-specific options such as:
 	// - voice_type: The type of voice to use (male/female)
 	// - language: The language code (en-US, vi-VN, etc.)
@@ -37,12 +119,45 @@ type SendVoiceRequest struct {
 	Options map[string]interface{} `json:"options,omitempty"`
 }
 
+// Content returns the message content to send
+// It returns Body if set, otherwise Template (or DefaultTemplate) rendered with Data
+func (r *SendSMSRequest) Content() string {
+	if r.Body != "" {
+		return r.Body
+	}
+
+	template := r.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+
+	return r.Message.Render(template, r.Data)
+}
+
+// Content returns the voice script to use
+// It returns Body if set, otherwise Template (or DefaultTemplate) rendered with Data
+func (r *SendVoiceRequest) Content() string {
+	if r.Body != "" {
+		return r.Body
+	}
+
+	template := r.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+
+	return r.Message.Render(template, r.Data)
+}
+
 // Validate performs basic validation on a SendSMSRequest
+// The recipient number is normalized to E.164, reading national numbers in Region
 func (r *SendSMSRequest) Validate() error {
-	// Validate phone numbers
-	if !ValidatePhoneNumber(r.Message.To) {
-		return &ValidationError{Field: "to", Message: "invalid recipient phone number"}
+	// Validate and normalize the recipient number
+	to, err := normalizeRecipient(r.Message.To, r.Region)
+	if err != nil {
+		return err
 	}
+	r.Message.To = to
 
 	// Ensure From is not empty
 	if r.Message.From == "" {
@@ -53,11 +168,14 @@ func (r *SendSMSRequest) Validate() error {
 }
 
 // Validate performs basic validation on a SendVoiceRequest
+// The recipient number is normalized to E.164, reading national numbers in Region
 func (r *SendVoiceRequest) Validate() error {
-	// Validate phone numbers
-	if !ValidatePhoneNumber(r.Message.To) {
-		return &ValidationError{Field: "to", Message: "invalid recipient phone number"}
+	// Validate and normalize the recipient number
+	to, err := normalizeRecipient(r.Message.To, r.Region)
+	if err != nil {
+		return err
 	}
+	r.Message.To = to
 
 	// Ensure From is not empty
 	if r.Message.From == "" {
@@ -67,13 +185,33 @@ func (r *SendVoiceRequest) Validate() error {
 	return nil
 }
 
+// normalizeRecipient parses a recipient number and returns it in E.164 format
+func normalizeRecipient(to, region string) (string, error) {
+	number, err := ParsePhoneNumber(to, region)
+	if err != nil {
+		return "", &ValidationError{Field: "to", Message: "invalid recipient phone number", Err: err}
+	}
+	return number.E164(), nil
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
 	Message string
+
+	// Err is the underlying error, if any
+	Err error
 }
 
 // Error returns the error message
 func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("validation error: %s - %s: %v", e.Field, e.Message, e.Err)
+	}
 	return fmt.Sprintf("validation error: %s - %s", e.Field, e.Message)
 }
+
+// Unwrap returns the underlying error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -45,6 +45,70 @@ type SendSMSResponse struct {
 	// Currency is the currency of the cost (if cost is provided)
 	Currency string `json:"currency,omitempty"`
 
+	// Body is the rendered message content that was sent
+	Body string `json:"body,omitempty"`
+
+	// Encoding is the encoding the body is sent with (GSM-7 or UCS-2)
+	Encoding Encoding `json:"encoding,omitempty"`
+
+	// Segments is the number of SMS parts the body takes
+	Segments int `json:"segments,omitempty"`
+
+	// Transliteration reports the characters replaced to keep the body in GSM-7
+	// It is nil when transliteration was not enabled for the message
+	Transliteration *Transliteration `json:"transliteration,omitempty"`
+
+	// Route is the name of the routing rule that selected the provider, if any
+	Route string `json:"route,omitempty"`
+
+	// Carrier is the detected carrier of a Vietnamese recipient, if known
+	Carrier Carrier `json:"carrier,omitempty"`
+
+	// ProviderResponse contains the raw response from the provider
+	ProviderResponse map[string]interface{} `json:"provider_response,omitempty"`
+
+	// Attempts lists the providers that were tried, in order, and why each one failed
+	Attempts []ProviderAttempt `json:"attempts,omitempty"`
+}
+
+// ProviderAttempt records the outcome of sending through a single provider
+type ProviderAttempt struct {
+	// Provider is the name of the provider that was tried
+	Provider string `json:"provider"`
+
+	// Error is the reason the provider failed (empty if it succeeded)
+	Error string `json:"error,omitempty"`
+
+	// Category classifies the failure when the provider returned a ProviderError
+	Category ErrorCategory `json:"category,omitempty"`
+
+	// Err is the original error returned for this provider
+	Err error `json:"-"`
+}
+
+// MessageStatusResponse represents the status of a sent message as reported by its provider
+type MessageStatusResponse struct {
+	// MessageID is the unique identifier assigned by the provider
+	MessageID string `json:"message_id"`
+
+	// Status is the normalized delivery status (pending, sent, delivered, failed, unknown)
+	Status MessageStatus `json:"status"`
+
+	// RawStatus is the status exactly as the provider reported it
+	RawStatus string `json:"raw_status"`
+
+	// Provider is the name of the provider that sent the message
+	Provider string `json:"provider"`
+
+	// ErrorCode is the provider error code when delivery failed (if any)
+	ErrorCode string `json:"error_code,omitempty"`
+
+	// ErrorMessage describes why delivery failed (if any)
+	ErrorMessage string `json:"error_message,omitempty"`
+
+	// UpdatedAt is when the provider last updated the status (zero if not reported)
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
 	// ProviderResponse contains the raw response from the provider
 	ProviderResponse map[string]interface{} `json:"provider_response,omitempty"`
 }
@@ -107,8 +171,14 @@ type SendVoiceResponse struct {
 	// Currency is the currency of the cost (if cost is provided)
 	Currency string `json:"currency,omitempty"`
 
+	// Body is the rendered voice script that was used
+	Body string `json:"body,omitempty"`
+
 	// ProviderResponse contains the raw response from the provider
 	ProviderResponse map[string]interface{} `json:"provider_response,omitempty"`
+
+	// Attempts lists the providers that were tried, in order, and why each one failed
+	Attempts []ProviderAttempt `json:"attempts,omitempty"`
 }
 
 // String returns a string representation of the SendSMSResponse
@@ -116,6 +186,11 @@ func (r *SendSMSResponse) String() string {
 	return fmt.Sprintf("SMS [%s] via %s: %s", r.MessageID, r.Provider, r.Status)
 }
 
+// String returns a string representation of the MessageStatusResponse
+func (r *MessageStatusResponse) String() string {
+	return fmt.Sprintf("SMS [%s] via %s: %s (%s)", r.MessageID, r.Provider, r.Status, r.RawStatus)
+}
+
 // String returns a string representation of the SendVoiceResponse
 func (r *SendVoiceResponse) String() string {
 	return fmt.Sprintf("Voice Call [%s] via %s: %s (Duration: %ds)",
@@ -0,0 +1,334 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MissingVariablesError is returned by strict rendering when placeholders have no value
+type MissingVariablesError struct {
+	// Variables lists the unresolved placeholder names in order of first appearance
+	Variables []string
+}
+
+// Error returns the error message
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("missing template variables: %s", strings.Join(e.Variables, ", "))
+}
+
+// templateToken is a piece of a tokenized template: either literal text or a placeholder
+type templateToken struct {
+	// text is the literal text, or the placeholder source without braces
+	text string
+
+	// placeholder reports whether the token is a {...} placeholder
+	placeholder bool
+}
+
+// tokenizeTemplate splits a template into literal text and placeholders.
+// "{{" and "}}" are escapes for literal braces. In lenient mode malformed braces are kept
+// as literal text; otherwise they are reported as errors.
+func tokenizeTemplate(template string, lenient bool) ([]templateToken, error) {
+	var tokens []templateToken
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, templateToken{text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+
+		// Escaped braces
+		if (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+
+		if c == '}' {
+			if !lenient {
+				return nil, fmt.Errorf("unmatched '}' at position %d (use '}}' for a literal brace)", i)
+			}
+			literal.WriteByte(c)
+			continue
+		}
+
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+
+		// Find the end of the placeholder
+		end := strings.IndexAny(template[i+1:], "{}")
+		switch {
+		case end < 0:
+			if !lenient {
+				return nil, fmt.Errorf("unclosed '{' at position %d (use '{{' for a literal brace)", i)
+			}
+		case template[i+1+end] == '{':
+			if !lenient {
+				return nil, fmt.Errorf("nested '{' at position %d", i+1+end)
+			}
+		case strings.TrimSpace(template[i+1:i+1+end]) == "":
+			if !lenient {
+				return nil, fmt.Errorf("empty placeholder at position %d", i)
+			}
+		default:
+			flush()
+			tokens = append(tokens, templateToken{text: template[i+1 : i+1+end], placeholder: true})
+			i += end + 1
+			continue
+		}
+
+		literal.WriteByte(c)
+	}
+	flush()
+
+	return tokens, nil
+}
+
+// filterCall is a single "|name:arg" step of a placeholder
+type filterCall struct {
+	name string
+	arg  string
+}
+
+// templateNode is an element of a parsed template
+type templateNode struct {
+	// source is the literal text, or the placeholder source without braces
+	source string
+
+	// key is the variable name of a placeholder or section (empty for literal text)
+	key string
+
+	// filters are applied in order to a placeholder value
+	filters []filterCall
+
+	// section reports whether the node is a {?key}...{/key} or {!key}...{/key} section
+	section bool
+
+	// inverted sections render their children when the key is missing or empty
+	inverted bool
+
+	// children are the nodes inside a section
+	children []*templateNode
+}
+
+// parseTemplate parses a template into nodes.
+// Placeholders have the form {key}, {key|filter} or {key|filter:arg|filter}.
+// {?key}...{/key} renders its content only when key has a non-empty value,
+// {!key}...{/key} only when it does not. In lenient mode unbalanced section tags are kept
+// as literal text; otherwise they, unknown filters and malformed braces are reported as errors.
+func parseTemplate(template string, lenient bool) ([]*templateNode, error) {
+	tokens, err := tokenizeTemplate(template, lenient)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &templateNode{section: true}
+	stack := []*templateNode{root}
+
+	for _, token := range tokens {
+		current := stack[len(stack)-1]
+
+		if !token.placeholder {
+			current.children = append(current.children, &templateNode{source: token.text})
+			continue
+		}
+
+		source := strings.TrimSpace(token.text)
+		switch source[0] {
+		case '?', '!':
+			node := &templateNode{
+				source:   token.text,
+				key:      strings.TrimSpace(source[1:]),
+				section:  true,
+				inverted: source[0] == '!',
+			}
+			current.children = append(current.children, node)
+			stack = append(stack, node)
+			continue
+
+		case '/':
+			key := strings.TrimSpace(source[1:])
+			if len(stack) > 1 && current.key == key {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			if !lenient {
+				return nil, fmt.Errorf("unexpected section end {%s}", token.text)
+			}
+			// Keep the stray section end as text
+			current.children = append(current.children, &templateNode{source: "{" + token.text + "}"})
+			continue
+		}
+
+		node, err := parsePlaceholder(token.text, lenient)
+		if err != nil {
+			return nil, err
+		}
+		current.children = append(current.children, node)
+	}
+
+	if len(stack) > 1 && !lenient {
+		return nil, fmt.Errorf("unclosed section {%s}", stack[len(stack)-1].source)
+	}
+
+	// Keep unclosed section openers as text, innermost first. An open section is always
+	// the last child of its parent, so its content is spliced back in after the opener.
+	for i := len(stack) - 1; i > 0; i-- {
+		parent, node := stack[i-1], stack[i]
+		opener := &templateNode{source: "{" + node.source + "}"}
+		parent.children = append(append(parent.children[:len(parent.children)-1], opener), node.children...)
+	}
+
+	return root.children, nil
+}
+
+// parsePlaceholder parses the source of a {key|filter:arg} placeholder
+func parsePlaceholder(source string, lenient bool) (*templateNode, error) {
+	parts := splitUnquoted(source, '|')
+
+	node := &templateNode{source: source, key: strings.TrimSpace(parts[0])}
+	if node.key == "" && !lenient {
+		return nil, fmt.Errorf("missing variable name in {%s}", source)
+	}
+
+	for _, part := range parts[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), ":")
+		call := filterCall{name: strings.TrimSpace(name), arg: unquote(strings.TrimSpace(arg))}
+
+		if _, ok := lookupTemplateFilter(call.name); !ok && call.name != "default" && !lenient {
+			return nil, fmt.Errorf("unknown filter '%s' in {%s}", call.name, source)
+		}
+		node.filters = append(node.filters, call)
+	}
+
+	return node, nil
+}
+
+// splitUnquoted splits s on sep, ignoring separators inside single or double quotes
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// unquote removes matching single or double quotes around s
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// templateRenderer renders parsed template nodes and collects problems
+type templateRenderer struct {
+	lookup  func(key string) (interface{}, bool)
+	result  strings.Builder
+	missing []string
+	seen    map[string]bool
+	errs    []error
+}
+
+// render writes the nodes to the result
+func (r *templateRenderer) render(nodes []*templateNode) {
+	for _, node := range nodes {
+		switch {
+		case node.section:
+			value, exists := r.lookup(node.key)
+			if isTruthy(value, exists) != node.inverted {
+				r.render(node.children)
+			}
+		case node.key == "":
+			r.result.WriteString(node.source)
+		default:
+			r.renderPlaceholder(node)
+		}
+	}
+}
+
+// renderPlaceholder resolves a placeholder and applies its filters.
+// Placeholders that cannot be rendered are written back unchanged.
+func (r *templateRenderer) renderPlaceholder(node *templateNode) {
+	value, exists := r.lookup(node.key)
+
+	for _, call := range node.filters {
+		if call.name == "default" {
+			if !exists || value == nil || value == "" {
+				value, exists = call.arg, true
+			}
+			continue
+		}
+
+		if !exists {
+			break
+		}
+
+		filter, ok := lookupTemplateFilter(call.name)
+		if !ok {
+			r.errs = append(r.errs, fmt.Errorf("unknown filter '%s' in {%s}", call.name, node.source))
+			r.result.WriteString("{" + node.source + "}")
+			return
+		}
+
+		var err error
+		if value, err = filter(value, call.arg); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("filter '%s' in {%s}: %w", call.name, node.source, err))
+			r.result.WriteString("{" + node.source + "}")
+			return
+		}
+	}
+
+	if !exists {
+		r.result.WriteString("{" + node.source + "}")
+		if !r.seen[node.key] {
+			r.seen[node.key] = true
+			r.missing = append(r.missing, node.key)
+		}
+		return
+	}
+
+	r.result.WriteString(fmt.Sprintf("%v", value))
+}
+
+// renderTemplate expands a template using lookup.
+// Placeholders that could not be rendered are kept in the output as written; the names of
+// unresolved variables and any filter errors are returned alongside the result.
+func renderTemplate(template string, lookup func(key string) (interface{}, bool)) (string, []string, error) {
+	// Lenient parsing never fails
+	nodes, _ := parseTemplate(template, true)
+
+	r := &templateRenderer{lookup: lookup, seen: make(map[string]bool)}
+	r.render(nodes)
+
+	return r.result.String(), r.missing, errors.Join(r.errs...)
+}
+
+// ValidateTemplate checks a template for placeholders that can never be resolved,
+// such as unbalanced braces or sections, empty placeholder names and unknown filters
+func ValidateTemplate(template string) error {
+	_, err := parseTemplate(template, false)
+	return err
+}
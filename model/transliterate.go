@@ -0,0 +1,133 @@
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CharacterReplacement records a character that transliteration replaced
+type CharacterReplacement struct {
+	// From is the original character
+	From string `json:"from"`
+
+	// To is the GSM-7 replacement (may be empty or several characters)
+	To string `json:"to"`
+
+	// Count is the number of times the character was replaced
+	Count int `json:"count"`
+}
+
+// Transliteration reports what TransliterateGSM7 changed in a text
+type Transliteration struct {
+	// Replacements lists the replaced characters in order of first appearance
+	Replacements []CharacterReplacement `json:"replacements,omitempty"`
+
+	// Unmapped lists the distinct non-GSM-7 characters that have no replacement
+	Unmapped []string `json:"unmapped,omitempty"`
+}
+
+// Changed reports whether any character was replaced
+func (t *Transliteration) Changed() bool {
+	return len(t.Replacements) > 0
+}
+
+// vietnameseLetters maps each base letter to its Vietnamese variants with diacritics
+var vietnameseLetters = map[string]string{
+	"a": "àáảãạăằắẳẵặâầấẩẫậ",
+	"A": "ÀÁẢÃẠĂẰẮẲẴẶÂẦẤẨẪẬ",
+	"d": "đ",
+	"D": "Đ",
+	"e": "èéẻẽẹêềếểễệ",
+	"E": "ÈÉẺẼẸÊỀẾỂỄỆ",
+	"i": "ìíỉĩị",
+	"I": "ÌÍỈĨỊ",
+	"o": "òóỏõọôồốổỗộơờớởỡợ",
+	"O": "ÒÓỎÕỌÔỒỐỔỖỘƠỜỚỞỠỢ",
+	"u": "ùúủũụưừứửữự",
+	"U": "ÙÚỦŨỤƯỪỨỬỮỰ",
+	"y": "ỳýỷỹỵ",
+	"Y": "ỲÝỶỸỴ",
+}
+
+// gsm7Replacements maps other common non-GSM-7 characters to GSM-7 equivalents
+var gsm7Replacements = map[rune]string{
+	// Latin letters with diacritics
+	'â': "a", 'Â': "A", 'ç': "c", 'ë': "e", 'Ë': "E", 'î': "i", 'Î': "I", 'ï': "i", 'Ï': "I",
+	'û': "u", 'Û': "U", 'ÿ': "y", 'Ÿ': "Y", 'š': "s", 'Š': "S", 'ž': "z", 'Ž': "Z",
+	'č': "c", 'Č': "C", 'ć': "c", 'Ć': "C", 'ł': "l", 'Ł': "L", 'ń': "n", 'Ń': "N",
+	'ő': "o", 'Ő': "O", 'ű': "u", 'Ű': "U", 'ś': "s", 'Ś': "S", 'ź': "z", 'Ź': "Z",
+	'ż': "z", 'Ż': "Z", 'ę': "e", 'Ę': "E", 'ą': "a", 'Ą': "A", 'ğ': "g", 'Ğ': "G",
+	'ş': "s", 'Ş': "S", 'ı': "i", 'İ': "I", 'œ': "oe", 'Œ': "OE",
+
+	// Punctuation and symbols
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '`': "'", '´': "'",
+	'“': "\"", '”': "\"", '„': "\"", '«': "\"", '»': "\"",
+	'–': "-", '—': "-", '‐': "-", '‑': "-", '−': "-",
+	'…': "...", '•': "*", '·': ".", '×': "x", '÷': "/",
+	'₫': "d", '©': "(c)", '®': "(R)", '™': "TM",
+
+	// Whitespace
+	'\t': " ", '\u00a0': " ", '\u2009': " ", '\u202f': " ", '\u200b': "",
+}
+
+// transliterationTable combines the Vietnamese letters and other replacements
+var transliterationTable = buildTransliterationTable()
+
+// buildTransliterationTable builds the rune lookup table used by TransliterateGSM7
+func buildTransliterationTable() map[rune]string {
+	table := make(map[rune]string, len(gsm7Replacements)+140)
+	for base, variants := range vietnameseLetters {
+		for _, r := range variants {
+			table[r] = base
+		}
+	}
+	for r, replacement := range gsm7Replacements {
+		table[r] = replacement
+	}
+	return table
+}
+
+// TransliterateGSM7 rewrites text so that it can be sent with the GSM-7 alphabet.
+// Vietnamese diacritics are stripped ("Mã OTP của bạn" becomes "Ma OTP cua ban"), including
+// on letters such as "é" or "à" that GSM-7 supports, so words are written consistently.
+// The text is NFC-normalized first, so decomposed input (a base letter followed by
+// combining marks) is handled like its precomposed form. Other common characters are
+// mapped to GSM-7 equivalents. Characters without a replacement are kept and reported
+// in Unmapped.
+func TransliterateGSM7(text string) (string, Transliteration) {
+	text = norm.NFC.String(text)
+
+	var report Transliteration
+	var result strings.Builder
+	result.Grow(len(text))
+
+	replaced := make(map[rune]int)
+	unmapped := make(map[rune]bool)
+
+	for _, r := range text {
+		replacement, ok := transliterationTable[r]
+		if !ok {
+			if !IsGSM7Rune(r) && !unmapped[r] {
+				unmapped[r] = true
+				report.Unmapped = append(report.Unmapped, string(r))
+			}
+			result.WriteRune(r)
+			continue
+		}
+
+		if index, seen := replaced[r]; seen {
+			report.Replacements[index].Count++
+		} else {
+			replaced[r] = len(report.Replacements)
+			report.Replacements = append(report.Replacements, CharacterReplacement{
+				From:  string(r),
+				To:    replacement,
+				Count: 1,
+			})
+		}
+		result.WriteString(replacement)
+	}
+
+	return result.String(), report
+}
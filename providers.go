@@ -0,0 +1,146 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-fork/sms/client"
+	"github.com/go-fork/sms/config"
+	"github.com/go-fork/sms/model"
+)
+
+// ErrNoProviderFactory is matched by errors.Is when a configured provider has no constructor
+var ErrNoProviderFactory = errors.New("no factory registered for provider")
+
+// ProviderConstructor builds a provider from its section of the module configuration,
+// sending requests through the given HTTP client. The NewProviderFromConfig function
+// of each adapter has this signature.
+type ProviderConstructor func(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error)
+
+var (
+	// factoriesMu guards factories
+	factoriesMu sync.RWMutex
+
+	// factories holds the provider constructors registered by adapter packages, by provider name
+	factories = make(map[string]ProviderConstructor)
+)
+
+// RegisterProviderFactory makes a provider constructor available to NewModuleFromConfig under
+// the given provider name. Adapter packages register themselves when they are imported.
+// Registering a factory with the name of an existing one replaces it.
+func RegisterProviderFactory(name string, factory ProviderConstructor) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("invalid provider factory '%s'", name)
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factories[name] = factory
+	return nil
+}
+
+// MustRegisterProviderFactory works like RegisterProviderFactory but panics if the factory
+// cannot be registered. It is meant for the init functions of adapter packages.
+func MustRegisterProviderFactory(name string, factory ProviderConstructor) {
+	if err := RegisterProviderFactory(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// ProviderFactories returns the names of the registered provider factories, sorted
+func ProviderFactories() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewModuleFromConfig creates a module from a parsed configuration and registers a provider for
+// every section under providers, using the factories registered by the imported adapter packages:
+//
+//	import _ "github.com/go-fork/sms/adapters/twilio"
+//
+// If any provider cannot be built, the returned error joins the errors of all of them.
+func NewModuleFromConfig(cfg *config.Config) (*Module, error) {
+	module, err := NewModuleWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	factoriesMu.RLock()
+	constructors := make(map[string]ProviderConstructor, len(factories))
+	for name, factory := range factories {
+		constructors[name] = factory
+	}
+	factoriesMu.RUnlock()
+
+	if err := module.LoadProviders(constructors); err != nil {
+		return nil, err
+	}
+
+	return module, nil
+}
+
+// LoadProviders builds and registers a provider for every section under providers in the
+// configuration, using the constructor registered under the same name. All the providers
+// share one HTTP client created from the HTTP settings of the configuration.
+// If any provider cannot be built, none are registered and the returned error joins the
+// errors of all of them; providers without a constructor match ErrNoProviderFactory.
+func (m *Module) LoadProviders(constructors map[string]ProviderConstructor) error {
+	names := make([]string, 0, len(m.config.Providers))
+	for name := range m.config.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	httpClient, err := client.NewClient(m.config)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	var errs []error
+	providers := make([]model.Provider, 0, len(names))
+	for _, name := range names {
+		if _, err := m.GetProvider(name); err == nil {
+			errs = append(errs, fmt.Errorf("provider with name '%s' is already registered", name))
+			continue
+		}
+
+		constructor, ok := constructors[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w '%s'", ErrNoProviderFactory, name))
+			continue
+		}
+
+		provider, err := constructor(m.config, httpClient)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create provider '%s': %w", name, err))
+			continue
+		}
+		if provider.Name() != name {
+			errs = append(errs, fmt.Errorf("constructor for provider '%s' returned provider '%s'", name, provider.Name()))
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, provider := range providers {
+		if err := m.AddProvider(provider); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/go-fork/sms/ratelimit"
+)
+
+// acquireProviderToken takes a token from the rate limiter of a provider, if it has one.
+// With failFast it returns a *ratelimit.Error at once when no token is free; otherwise it
+// waits for a token until ctx is done.
+func (m *Module) acquireProviderToken(ctx context.Context, name string, failFast bool) error {
+	limiter := m.providerLimiter(name)
+	if limiter == nil {
+		return nil
+	}
+
+	if failFast {
+		return limiter.TryAcquire()
+	}
+	return limiter.Wait(ctx)
+}
+
+// acquireRecipientToken takes a token from the rate limit of a recipient, if one is configured.
+// Recipient limits never wait: they exist to stop message floods, not to pace them.
+func (m *Module) acquireRecipientToken(to string) error {
+	if m.recipientLimiter == nil {
+		return nil
+	}
+	return m.recipientLimiter.TryAcquire(to)
+}
+
+// providerLimiter returns the rate limiter of a provider, creating it on first use,
+// or nil when the provider has no rate limit
+func (m *Module) providerLimiter(name string) *ratelimit.Limiter {
+	limit, ok := m.config.GetProviderRateLimit(name)
+	if !ok {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limiter, ok := m.limiters[name]; ok {
+		return limiter
+	}
+
+	if m.limiters == nil {
+		m.limiters = make(map[string]*ratelimit.Limiter)
+	}
+
+	limiter := ratelimit.New(name, limit.Rate, limit.Burst)
+	m.limiters[name] = limiter
+
+	return limiter
+}
+
+// failFast reports whether a send should skip rate-limited providers instead of waiting
+func (m *Module) failFast(override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return m.config.GetRateLimits().FailFast
+}
@@ -0,0 +1,241 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is matched by errors.Is when a call is rejected by a rate limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Error is returned when a limiter has no token for a call
+type Error struct {
+	// Name identifies the limit that was exceeded (e.g. "twilio" or "recipient +84912345678")
+	Name string
+
+	// RetryAfter is how long until a token is available
+	RetryAfter time.Duration
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limit exceeded for '%s', retry in %s", e.Name, e.RetryAfter)
+}
+
+// Is reports whether target is ErrRateLimited
+func (e *Error) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryDelay returns how long until a token is available
+func (e *Error) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// Limiter is a token bucket: it holds up to burst tokens and refills at rate tokens per second.
+// Each call takes one token. It is safe for concurrent use.
+type Limiter struct {
+	// name identifies the limiter in errors
+	name string
+
+	// rate is the number of tokens added per second
+	rate float64
+
+	// burst is the capacity of the bucket
+	burst float64
+
+	// now returns the current time
+	now func() time.Time
+
+	// mu guards tokens and last
+	mu sync.Mutex
+
+	// tokens is the number of tokens in the bucket; it is negative when waiters have reserved tokens ahead
+	tokens float64
+
+	// last is when tokens was last brought up to date
+	last time.Time
+}
+
+// New creates a full token bucket allowing rate calls per second on average and bursts of burst calls.
+// A burst of 0 or less allows bursts of one second's worth of calls (at least one).
+func New(name string, rate float64, burst int) *Limiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	l := &Limiter{
+		name:  name,
+		rate:  rate,
+		burst: float64(burst),
+		now:   time.Now,
+	}
+	l.tokens = l.burst
+	l.last = l.now()
+	return l
+}
+
+// Name returns the name of the limiter
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+// TryAcquire takes a token if one is available, or returns an *Error (matching ErrRateLimited)
+// with the time until the next token
+func (l *Limiter) TryAcquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(l.now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return nil
+	}
+
+	return &Error{Name: l.name, RetryAfter: l.delay(1 - l.tokens)}
+}
+
+// Wait takes a token, waiting until one is available or ctx is done. If ctx has a deadline
+// before the token would be available, it returns an *Error immediately instead of waiting.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+
+	// Reserve the token now so that concurrent waiters are served in order
+	l.tokens--
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = l.delay(-l.tokens)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return &Error{Name: l.name, RetryAfter: wait}
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reserved token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// full reports whether the bucket is full, i.e. the limiter has been idle
+func (l *Limiter) full() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(l.now())
+	return l.tokens >= l.burst
+}
+
+// refill adds the tokens earned since the last update. It must be called with mu held.
+func (l *Limiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+}
+
+// delay returns the time needed to earn the given number of tokens
+func (l *Limiter) delay(tokens float64) time.Duration {
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
+
+// Keyed keeps a separate token bucket per key, such as one per recipient.
+// Buckets that have refilled completely are dropped periodically, so idle keys do not accumulate.
+// It is safe for concurrent use.
+type Keyed struct {
+	// name prefixes the names of the per-key limiters
+	name string
+
+	// limit and period define each bucket: limit calls per period
+	limit  int
+	period time.Duration
+
+	// now returns the current time
+	now func() time.Time
+
+	// mu guards limiters and lastSweep
+	mu sync.Mutex
+
+	// limiters holds the bucket of each key
+	limiters map[string]*Limiter
+
+	// lastSweep is when idle buckets were last dropped
+	lastSweep time.Time
+}
+
+// NewKeyed creates per-key token buckets that each allow limit calls per period
+func NewKeyed(name string, limit int, period time.Duration) *Keyed {
+	k := &Keyed{
+		name:     name,
+		limit:    limit,
+		period:   period,
+		now:      time.Now,
+		limiters: make(map[string]*Limiter),
+	}
+	k.lastSweep = k.now()
+	return k
+}
+
+// TryAcquire takes a token from the bucket of key, or returns an *Error (matching ErrRateLimited)
+func (k *Keyed) TryAcquire(key string) error {
+	return k.limiter(key).TryAcquire()
+}
+
+// Wait takes a token from the bucket of key, waiting as Limiter.Wait does
+func (k *Keyed) Wait(ctx context.Context, key string) error {
+	return k.limiter(key).Wait(ctx)
+}
+
+// limiter returns the bucket of a key, creating it on first use
+func (k *Keyed) limiter(key string) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	if now.Sub(k.lastSweep) >= k.period {
+		for existing, l := range k.limiters {
+			if l.full() {
+				delete(k.limiters, existing)
+			}
+		}
+		k.lastSweep = now
+	}
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = New(k.name+" "+key, float64(k.limit)/k.period.Seconds(), k.limit)
+		l.now = k.now
+		l.last = now
+		k.limiters[key] = l
+	}
+	return l
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for limiter tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestLimiterTryAcquire(t *testing.T) {
+	clock := newFakeClock()
+	l := New("esms", 50, 2)
+	l.now, l.last = clock.Now, clock.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := l.TryAcquire(); err != nil {
+			t.Fatalf("Expected burst token %d, got %v", i+1, err)
+		}
+	}
+
+	err := l.TryAcquire()
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	var limitErr *Error
+	if !errors.As(err, &limitErr) || limitErr.Name != "esms" || limitErr.RetryAfter != 20*time.Millisecond {
+		t.Errorf("Expected esms error with 20ms retry, got %v", err)
+	}
+
+	clock.Advance(20 * time.Millisecond)
+	if err := l.TryAcquire(); err != nil {
+		t.Errorf("Expected a refilled token, got %v", err)
+	}
+}
+
+func TestLimiterDefaultBurst(t *testing.T) {
+	l := New("speedsms", 30, 0)
+	for i := 0; i < 30; i++ {
+		if err := l.TryAcquire(); err != nil {
+			t.Fatalf("Expected a burst of 30 tokens, failed at %d: %v", i+1, err)
+		}
+	}
+	if err := l.TryAcquire(); err == nil {
+		t.Error("Expected the bucket to be empty")
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Run("Waits for a token", func(t *testing.T) {
+		l := New("twilio", 100, 1)
+		l.TryAcquire()
+
+		start := time.Now()
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected to get a token, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+			t.Errorf("Expected to wait for the next token, waited %v", elapsed)
+		}
+	})
+
+	t.Run("Fails when the deadline is too close", func(t *testing.T) {
+		l := New("twilio", 1, 1)
+		l.TryAcquire()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := l.Wait(ctx)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+			t.Errorf("Expected to fail without waiting, waited %v", elapsed)
+		}
+
+		// The token reserved for the failed wait is given back
+		l.now = func() time.Time { return time.Now().Add(time.Second) }
+		if err := l.TryAcquire(); err != nil {
+			t.Errorf("Expected the reserved token to be returned, got %v", err)
+		}
+	})
+
+	t.Run("Stops when the context is canceled", func(t *testing.T) {
+		l := New("twilio", 10, 1)
+		l.TryAcquire()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+		}()
+
+		if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestKeyed(t *testing.T) {
+	clock := newFakeClock()
+	k := NewKeyed("recipient", 2, time.Hour)
+	k.now, k.lastSweep = clock.Now, clock.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := k.TryAcquire("+84912345678"); err != nil {
+			t.Fatalf("Expected token %d, got %v", i+1, err)
+		}
+	}
+
+	err := k.TryAcquire("+84912345678")
+	var limitErr *Error
+	if !errors.As(err, &limitErr) || limitErr.Name != "recipient +84912345678" {
+		t.Fatalf("Expected a recipient rate limit error, got %v", err)
+	}
+	if limitErr.RetryAfter != 30*time.Minute {
+		t.Errorf("Expected 30m until the next token, got %v", limitErr.RetryAfter)
+	}
+
+	if err := k.TryAcquire("+84987654321"); err != nil {
+		t.Errorf("Expected other recipients to have their own bucket, got %v", err)
+	}
+
+	// Idle buckets are dropped once they have refilled
+	clock.Advance(2 * time.Hour)
+	k.TryAcquire("+14155552671")
+	if len(k.limiters) != 1 {
+		t.Errorf("Expected idle buckets to be dropped, have %d", len(k.limiters))
+	}
+}
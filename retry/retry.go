@@ -4,16 +4,73 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/go-fork/sms/model"
 )
 
 // ErrMaxAttemptsReached is returned when all retry attempts have failed
 var ErrMaxAttemptsReached = errors.New("maximum retry attempts reached")
 
+// DelayedError is implemented by errors that carry a server-suggested delay
+// before the next attempt, such as a Retry-After header on a 429 or 503 response
+type DelayedError interface {
+	error
+
+	// RetryDelay returns the suggested delay, or 0 if the server did not suggest one
+	RetryDelay() time.Duration
+}
+
+// Strategy selects how the delay between attempts is computed
+type Strategy string
+
+const (
+	// StrategyExponential waits InitialDelay, then multiplies the delay by Multiplier after each attempt
+	StrategyExponential Strategy = "exponential"
+
+	// StrategyFullJitter waits a random delay between 0 and the exponential delay
+	StrategyFullJitter Strategy = "full_jitter"
+
+	// StrategyEqualJitter waits half the exponential delay plus a random delay up to the other half
+	StrategyEqualJitter Strategy = "equal_jitter"
+
+	// StrategyDecorrelatedJitter waits a random delay between InitialDelay and three times the previous delay
+	StrategyDecorrelatedJitter Strategy = "decorrelated_jitter"
+
+	// StrategyConstant always waits InitialDelay
+	StrategyConstant Strategy = "constant"
+)
+
+// Strategies returns the supported backoff strategies
+func Strategies() []Strategy {
+	return []Strategy{
+		StrategyExponential,
+		StrategyFullJitter,
+		StrategyEqualJitter,
+		StrategyDecorrelatedJitter,
+		StrategyConstant,
+	}
+}
+
+// IsValid reports whether the strategy is supported; the empty strategy means StrategyExponential
+func (s Strategy) IsValid() bool {
+	if s == "" {
+		return true
+	}
+	for _, strategy := range Strategies() {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
 // Config holds retry configuration settings
 type Config struct {
 	// MaxAttempts is the maximum number of retry attempts (including the initial attempt)
@@ -28,6 +85,13 @@ type Config struct {
 	// Multiplier is the factor by which the delay increases after each attempt
 	Multiplier float64
 
+	// Strategy selects how delays are computed; empty means StrategyExponential
+	Strategy Strategy
+
+	// OnRetry is called before waiting for the next attempt with the number of the attempt
+	// that failed (starting at 1), its error and the delay before the next attempt
+	OnRetry func(attempt int, err error, nextDelay time.Duration)
+
 	// RetriableErrors is an optional custom function to determine if an error is retriable
 	// If nil, the default IsRetriable function will be used
 	RetriableErrors func(error) bool
@@ -40,19 +104,22 @@ func DefaultConfig() Config {
 		InitialDelay:    500 * time.Millisecond,
 		MaxDelay:        30 * time.Second,
 		Multiplier:      2.0,
+		Strategy:        StrategyExponential,
 		RetriableErrors: nil,
 	}
 }
 
-// Do executes the given function with exponential backoff retry logic
-// It respects context cancellation and deadlines
+// Do executes the given function with backoff retry logic
+// The first retry waits InitialDelay; later delays follow config.Strategy.
+// It respects context cancellation and deadlines. When the error carries a
+// server-suggested delay (see DelayedError), that delay is used instead, capped at MaxDelay
 func Do(ctx context.Context, config Config, fn func() error) error {
 	if config.MaxAttempts <= 0 {
 		return errors.New("retry attempts must be greater than 0")
 	}
 
 	var err error
-	delay := config.InitialDelay
+	var delay time.Duration
 
 	// Use default IsRetriable if no custom function is provided
 	isRetriable := IsRetriable
@@ -70,23 +137,34 @@ func Do(ctx context.Context, config Config, fn func() error) error {
 			return nil
 		}
 
+		// The caller gave up: the error may be a timeout caused by its own deadline
+		if ctx.Err() != nil {
+			return err
+		}
+
 		// If this is the last attempt or the error is not retriable, return the error
 		if attempt == config.MaxAttempts-1 || !isRetriable(err) {
 			if attempt == config.MaxAttempts-1 {
-				return fmt.Errorf("%w: %v", ErrMaxAttemptsReached, err)
+				return fmt.Errorf("%w: %w", ErrMaxAttemptsReached, err)
 			}
 			return err
 		}
 
-		// Calculate next delay with exponential backoff
-		nextDelay := time.Duration(float64(delay) * config.Multiplier)
-		if nextDelay > config.MaxDelay {
-			nextDelay = config.MaxDelay
+		// Calculate the backoff delay for this retry
+		delay = config.backoff(attempt, delay)
+
+		// A delay suggested by the server replaces the backoff delay, within MaxDelay
+		wait := delay
+		if suggested, ok := RetryAfter(err); ok {
+			wait = config.capDelay(suggested)
+		}
+
+		if config.OnRetry != nil {
+			config.OnRetry(attempt+1, err, wait)
 		}
-		delay = nextDelay
 
 		// Wait for the delay or until context is canceled
-		timer := time.NewTimer(delay)
+		timer := time.NewTimer(wait)
 		select {
 		case <-ctx.Done():
 			timer.Stop()
@@ -100,6 +178,62 @@ func Do(ctx context.Context, config Config, fn func() error) error {
 	return err
 }
 
+// backoff returns the delay before retry number retry+1 (the first retry is 0),
+// given the delay that was used before the previous retry
+func (c Config) backoff(retry int, previous time.Duration) time.Duration {
+	multiplier := c.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	switch c.Strategy {
+	case StrategyConstant:
+		return c.capDelay(c.InitialDelay)
+
+	case StrategyDecorrelatedJitter:
+		if previous < c.InitialDelay {
+			previous = c.InitialDelay
+		}
+		upper := c.capDelay(3 * previous)
+		return c.InitialDelay + randomDelay(upper-c.InitialDelay)
+	}
+
+	// The exponential delay grows from InitialDelay; jitter strategies randomize it
+	exponential := time.Duration(math.MaxInt64)
+	if delay := float64(c.InitialDelay) * math.Pow(multiplier, float64(retry)); delay < float64(math.MaxInt64) {
+		exponential = time.Duration(delay)
+	}
+	exponential = c.capDelay(exponential)
+
+	switch c.Strategy {
+	case StrategyFullJitter:
+		return randomDelay(exponential)
+	case StrategyEqualJitter:
+		return exponential/2 + randomDelay(exponential-exponential/2)
+	default:
+		return exponential
+	}
+}
+
+// capDelay limits a delay to MaxDelay, when MaxDelay is set
+func (c Config) capDelay(delay time.Duration) time.Duration {
+	if c.MaxDelay > 0 && delay > c.MaxDelay {
+		return c.MaxDelay
+	}
+	return delay
+}
+
+// randomDelay returns a random delay between 0 and max inclusive
+func randomDelay(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	if max == math.MaxInt64 {
+		return time.Duration(rand.Int64N(int64(max)))
+	}
+	return time.Duration(rand.Int64N(int64(max) + 1))
+}
+
 // DoWithOptions is a simpler version of Do that takes individual parameters instead of a Config
 func DoWithOptions(ctx context.Context, attempts int, initialDelay time.Duration, fn func() error) error {
 	config := Config{
@@ -111,62 +245,75 @@ func DoWithOptions(ctx context.Context, attempts int, initialDelay time.Duration
 	return Do(ctx, config, fn)
 }
 
+// RetryAfter returns the server-suggested delay carried by the first DelayedError
+// in err's chain, if it is positive
+func RetryAfter(err error) (time.Duration, bool) {
+	var delayed DelayedError
+	if errors.As(err, &delayed) {
+		if delay := delayed.RetryDelay(); delay > 0 {
+			return delay, true
+		}
+	}
+	return 0, false
+}
+
 // IsRetriable determines if an error should be retried
-// Returns true for network errors, timeouts, and 5xx status codes
+// Provider errors are retried when their category is transient or rate limited.
+// Otherwise it returns true for network errors, timeouts, and 5xx or 429 status codes
 func IsRetriable(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	// Check for network errors
-	var netErr net.Error
-	if errors.As(err, &netErr) {
-		// Retry network errors, especially timeouts
-		return true
+	// Provider errors carry a normalized category
+	var providerErr *model.ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retriable()
 	}
 
-	// Check for specific error types
-	if errors.Is(err, context.DeadlineExceeded) ||
-		errors.Is(err, context.Canceled) {
-		// Don't retry context cancellation or deadline errors
-		return false
+	// For HTTP response errors, check the status code
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == 429
 	}
 
-	// Check for URL errors (often network-related)
+	// Check for URL errors from the HTTP client. Timeouts, including http.Client.Timeout
+	// (which also matches context.DeadlineExceeded), are retried; canceled requests are not.
+	// When the caller's own context is done, Do stops before asking.
 	var urlErr *url.Error
 	if errors.As(err, &urlErr) {
-		// URL errors are often temporary and retriable
-		return true
+		return urlErr.Timeout() || !errors.Is(urlErr, context.Canceled)
 	}
 
-	// Check for HTTP status code errors
-	// This assumes that HTTP errors contain the status code in the error message
-	// Adapt this logic based on how your application represents HTTP errors
-	errStr := err.Error()
-
-	// Check for 5xx errors (server errors)
-	if strings.Contains(errStr, "status code: 5") ||
-		strings.Contains(errStr, "500") ||
-		strings.Contains(errStr, "502") ||
-		strings.Contains(errStr, "503") ||
-		strings.Contains(errStr, "504") {
-		return true
+	// Don't retry bare context cancellation or deadline errors
+	// These are checked before net.Error because context.DeadlineExceeded is also a net.Error
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) {
+		return false
 	}
 
-	// Check for specific error messages that typically indicate temporary issues
-	if strings.Contains(strings.ToLower(errStr), "timeout") ||
-		strings.Contains(strings.ToLower(errStr), "connection refused") ||
-		strings.Contains(strings.ToLower(errStr), "connection reset") ||
-		strings.Contains(strings.ToLower(errStr), "temporary") ||
-		strings.Contains(strings.ToLower(errStr), "too many requests") ||
-		strings.Contains(strings.ToLower(errStr), "service unavailable") {
+	// Check for network errors
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		// Retry network errors, especially timeouts
 		return true
 	}
 
-	// For HTTP response errors, we can check more specifically
-	var httpErr *HTTPError
-	if errors.As(err, &httpErr) {
-		return httpErr.StatusCode >= 500 || httpErr.StatusCode == 429
+	// Check for specific error messages that typically indicate temporary issues
+	// Status codes are not matched in the text, since numbers such as amounts would be mistaken for them
+	errStr := strings.ToLower(err.Error())
+	for _, temporary := range []string{
+		"timeout",
+		"timed out",
+		"connection refused",
+		"connection reset",
+		"temporary",
+		"too many requests",
+		"service unavailable",
+	} {
+		if strings.Contains(errStr, temporary) {
+			return true
+		}
 	}
 
 	// Default to not retrying unknown errors
@@ -178,6 +325,9 @@ func IsRetriable(err error) bool {
 type HTTPError struct {
 	StatusCode int
 	Message    string
+
+	// RetryAfter is the delay requested by the response's Retry-After header (0 if none)
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface
@@ -185,6 +335,11 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP error: status code: %d, message: %s", e.StatusCode, e.Message)
 }
 
+// RetryDelay returns the delay requested by the server before the next attempt
+func (e *HTTPError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
 // NewHTTPError creates a new HTTPError
 func NewHTTPError(statusCode int, message string) *HTTPError {
 	return &HTTPError{
@@ -3,9 +3,16 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/go-fork/sms/model"
 )
 
 func TestDo(t *testing.T) {
@@ -172,6 +179,41 @@ func TestIsRetriable(t *testing.T) {
 			err:      errors.New("some error"),
 			expected: false,
 		},
+		{
+			name:     "Wrapped context deadline exceeded",
+			err:      fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			expected: false,
+		},
+		{
+			name:     "HTTP client timeout",
+			err:      &url.Error{Op: "Post", URL: "https://api.example.com", Err: fmt.Errorf("%w (Client.Timeout exceeded while awaiting headers)", context.DeadlineExceeded)},
+			expected: true,
+		},
+		{
+			name:     "Canceled HTTP request",
+			err:      &url.Error{Op: "Post", URL: "https://api.example.com", Err: context.Canceled},
+			expected: false,
+		},
+		{
+			name:     "Error with status code digits in message",
+			err:      errors.New("eSMS error: amount 500 is invalid"),
+			expected: false,
+		},
+		{
+			name:     "Transient provider error",
+			err:      &model.ProviderError{Provider: "esms", Code: "99", Category: model.ErrorCategoryTransient},
+			expected: true,
+		},
+		{
+			name:     "Rate limited provider error",
+			err:      &model.ProviderError{Provider: "twilio", HTTPStatus: 429, Category: model.ErrorCategoryRateLimited},
+			expected: true,
+		},
+		{
+			name:     "Invalid recipient provider error",
+			err:      fmt.Errorf("send failed: %w", &model.ProviderError{Provider: "twilio", Code: "21211", HTTPStatus: 500, Category: model.ErrorCategoryInvalidRecipient, Message: "service unavailable"}),
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -182,3 +224,206 @@ func TestIsRetriable(t *testing.T) {
 		})
 	}
 }
+
+func TestDoKeepsLastError(t *testing.T) {
+	providerErr := &model.ProviderError{Provider: "esms", Category: model.ErrorCategoryTransient}
+	config := Config{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2.0}
+
+	err := Do(context.Background(), config, func() error { return providerErr })
+
+	if !errors.Is(err, ErrMaxAttemptsReached) {
+		t.Errorf("Expected ErrMaxAttemptsReached, got %v", err)
+	}
+	var target *model.ProviderError
+	if !errors.As(err, &target) || target != providerErr {
+		t.Errorf("Expected the last provider error to be wrapped, got %v", err)
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	t.Run("Waits for the suggested delay", func(t *testing.T) {
+		config := Config{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Second, Multiplier: 2.0}
+		attempts := 0
+
+		start := time.Now()
+		err := Do(context.Background(), config, func() error {
+			attempts++
+			if attempts == 1 {
+				return &HTTPError{StatusCode: 429, RetryAfter: 50 * time.Millisecond}
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Expected to wait at least 50ms, waited %v", elapsed)
+		}
+	})
+
+	t.Run("Caps the suggested delay at MaxDelay", func(t *testing.T) {
+		config := Config{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, Multiplier: 2.0}
+		providerErr := &model.ProviderError{Provider: "twilio", Category: model.ErrorCategoryRateLimited, RetryAfter: time.Hour}
+
+		start := time.Now()
+		Do(context.Background(), config, func() error { return providerErr })
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the delay to be capped at MaxDelay, waited %v", elapsed)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	if delay, ok := RetryAfter(fmt.Errorf("send: %w", &HTTPError{StatusCode: 503, RetryAfter: 2 * time.Second})); !ok || delay != 2*time.Second {
+		t.Errorf("Expected 2s from wrapped HTTPError, got %v %v", delay, ok)
+	}
+	if _, ok := RetryAfter(&model.ProviderError{Category: model.ErrorCategoryRateLimited}); ok {
+		t.Error("Expected no delay when the provider did not suggest one")
+	}
+	if _, ok := RetryAfter(errors.New("timeout")); ok {
+		t.Error("Expected no delay for plain errors")
+	}
+}
+
+func TestDoOnRetry(t *testing.T) {
+	var attempts []int
+	var delays []time.Duration
+	config := Config{
+		MaxAttempts:  4,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     3 * time.Millisecond,
+		Multiplier:   2.0,
+		OnRetry: func(attempt int, err error, nextDelay time.Duration) {
+			attempts = append(attempts, attempt)
+			delays = append(delays, nextDelay)
+		},
+	}
+
+	Do(context.Background(), config, func() error { return &HTTPError{StatusCode: 503} })
+
+	// The first retry waits InitialDelay, then the delay doubles up to MaxDelay
+	expectedDelays := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}
+	if fmt.Sprint(delays) != fmt.Sprint(expectedDelays) {
+		t.Errorf("Expected delays %v, got %v", expectedDelays, delays)
+	}
+	if fmt.Sprint(attempts) != fmt.Sprint([]int{1, 2, 3}) {
+		t.Errorf("Expected attempts [1 2 3], got %v", attempts)
+	}
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	base := Config{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2.0}
+
+	tests := []struct {
+		strategy Strategy
+		retry    int
+		min, max time.Duration
+	}{
+		{StrategyExponential, 0, 100 * time.Millisecond, 100 * time.Millisecond},
+		{StrategyExponential, 2, 400 * time.Millisecond, 400 * time.Millisecond},
+		{StrategyExponential, 10, time.Second, time.Second},
+		{StrategyConstant, 5, 100 * time.Millisecond, 100 * time.Millisecond},
+		{StrategyFullJitter, 2, 0, 400 * time.Millisecond},
+		{StrategyEqualJitter, 2, 200 * time.Millisecond, 400 * time.Millisecond},
+		{StrategyDecorrelatedJitter, 0, 100 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.strategy, tt.retry), func(t *testing.T) {
+			config := base
+			config.Strategy = tt.strategy
+			for i := 0; i < 100; i++ {
+				delay := config.backoff(tt.retry, 0)
+				if delay < tt.min || delay > tt.max {
+					t.Fatalf("Expected delay between %v and %v, got %v", tt.min, tt.max, delay)
+				}
+			}
+		})
+	}
+
+	t.Run("Decorrelated jitter grows from the previous delay", func(t *testing.T) {
+		config := base
+		config.Strategy = StrategyDecorrelatedJitter
+		for i := 0; i < 100; i++ {
+			delay := config.backoff(3, 500*time.Millisecond)
+			if delay < 100*time.Millisecond || delay > time.Second {
+				t.Fatalf("Expected delay between 100ms and 1s, got %v", delay)
+			}
+		}
+	})
+}
+
+func TestStrategyIsValid(t *testing.T) {
+	for _, strategy := range append(Strategies(), "") {
+		if !strategy.IsValid() {
+			t.Errorf("Expected strategy %q to be valid", strategy)
+		}
+	}
+	if Strategy("fibonacci").IsValid() {
+		t.Error("Expected unknown strategy to be invalid")
+	}
+}
+
+func TestDoRetriesHTTPClientTimeouts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	send := func() error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	err := send()
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || !urlErr.Timeout() {
+		t.Fatalf("Expected a client timeout, got %v", err)
+	}
+	if !IsRetriable(err) {
+		t.Fatalf("Expected the client timeout to be retriable, got %v", err)
+	}
+
+	requests.Store(0)
+	config := Config{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+	err = Do(context.Background(), config, send)
+	if !errors.Is(err, ErrMaxAttemptsReached) {
+		t.Errorf("Expected every attempt to be made, got %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+
+	// A timeout caused by the caller's own deadline is not retried
+	requests.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client.Timeout = time.Second
+	err = Do(ctx, config, func() error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
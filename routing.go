@@ -0,0 +1,84 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/go-fork/sms/model"
+)
+
+// Route describes how the module would send an SMS
+type Route struct {
+	// Rule is the name of the routing rule that matched, empty if none did
+	Rule string `json:"rule,omitempty"`
+
+	// Provider is the provider the message is sent through first
+	Provider string `json:"provider"`
+
+	// Failover lists the providers tried next, in order, if Provider fails
+	Failover []string `json:"failover,omitempty"`
+
+	// Sender is the sender identifier the message is sent with
+	Sender string `json:"sender"`
+
+	// Carrier is the detected carrier of a Vietnamese recipient, if known
+	Carrier model.Carrier `json:"carrier,omitempty"`
+}
+
+// ResolveRoute reports which route an SMS would take without sending it
+func (m *Module) ResolveRoute(req model.SendSMSRequest) (Route, error) {
+	route, _, err := m.routeSMS(req)
+	return route, err
+}
+
+// routeSMS picks the providers and sender for an SMS.
+// A provider named on the request wins; otherwise the first matching routing rule picks the
+// provider and, when the request has no sender, the sender ID; otherwise the active provider
+// is used. Failover providers follow the chosen provider unless the request named one.
+func (m *Module) routeSMS(req model.SendSMSRequest) (Route, []model.Provider, error) {
+	route := Route{Sender: req.Message.From}
+
+	// Match rules against the normalized recipient number
+	msg := req.Message
+	if number, err := model.ParsePhoneNumber(msg.To, m.region(req.Region)); err == nil {
+		msg.To = number.E164()
+		route.Carrier = number.Carrier()
+	}
+
+	var chain []model.Provider
+	var err error
+
+	if req.Provider != "" {
+		chain, err = m.sendChain(req.Provider)
+	} else if rule, ok := m.config.MatchRoute(msg); ok {
+		route.Rule = rule.Name
+		if route.Sender == "" {
+			route.Sender = rule.Sender
+		}
+
+		chain, err = m.providerChain(rule.Provider)
+		if err != nil {
+			err = fmt.Errorf("route '%s': %w", rule.Name, err)
+		}
+	} else {
+		chain, err = m.providerChain("")
+	}
+
+	if err != nil {
+		return Route{}, nil, err
+	}
+
+	route.Provider = chain[0].Name()
+	for _, provider := range chain[1:] {
+		route.Failover = append(route.Failover, provider.Name())
+	}
+
+	return route, chain, nil
+}
+
+// region returns the region used to read national recipient numbers for a request
+func (m *Module) region(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return m.config.GetDefaultRegion()
+}
@@ -2,16 +2,27 @@ package sms
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/go-fork/sms/breaker"
 	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/ratelimit"
 	"github.com/go-fork/sms/retry"
+	"github.com/go-fork/sms/suppression"
 )
 
-// Module represents the main SMS module that manages providers and handles message sending
+// Module represents the main SMS module that manages providers and handles message sending.
+// It is safe for concurrent use; providers can be added, switched, replaced and removed
+// while messages are being sent.
 type Module struct {
+	// mu guards providers, activeProvider, breakers, suppressionList and the hooks
+	mu sync.RWMutex
+
 	// config holds the module configuration
 	config *config.Config
 
@@ -20,8 +31,30 @@ type Module struct {
 
 	// activeProvider is the currently active provider
 	activeProvider model.Provider
+
+	// retryHook is called before each retry of a provider attempt
+	retryHook RetryHook
+
+	// breakers holds the circuit breaker of each provider, created on first use
+	breakers map[string]*breaker.Breaker
+
+	// breakerHook is called after a provider's circuit breaker changes state
+	breakerHook BreakerHook
+
+	// limiters holds the rate limiter of each rate-limited provider, created on first use
+	limiters map[string]*ratelimit.Limiter
+
+	// recipientLimiter limits the messages sent to each recipient (nil if not configured)
+	recipientLimiter *ratelimit.Keyed
+
+	// suppressionList holds the numbers that must not receive SMS messages (nil if not configured)
+	suppressionList suppression.List
 }
 
+// RetryHook is called before a provider attempt is retried, with the provider name, the number
+// of the attempt that failed (starting at 1), its error and the delay before the next attempt
+type RetryHook func(provider string, attempt int, err error, nextDelay time.Duration)
+
 // NewModule creates a new SMS module instance with the given configuration file
 func NewModule(configFile string) (*Module, error) {
 	// Load configuration from file
@@ -30,10 +63,39 @@ func NewModule(configFile string) (*Module, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	return NewModuleWithConfig(cfg)
+}
+
+// NewModuleWithConfig creates a new SMS module instance from an already parsed configuration.
+// The configuration is validated; it must not be modified afterwards.
+func NewModuleWithConfig(cfg *config.Config) (*Module, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration is required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	// Create a new module with empty providers map
 	module := &Module{
 		config:    cfg,
 		providers: make(map[string]model.Provider),
+		breakers:  make(map[string]*breaker.Breaker),
+		limiters:  make(map[string]*ratelimit.Limiter),
+	}
+
+	if recipient := cfg.GetRateLimits().Recipient; recipient.Limit > 0 {
+		module.recipientLimiter = ratelimit.NewKeyed("recipient", recipient.Limit, recipient.Period)
+	}
+
+	if settings := cfg.GetSuppression(); settings.File != "" {
+		list, err := suppression.NewFile(settings.File)
+		if err != nil {
+			return nil, err
+		}
+		module.suppressionList = list
+	} else if settings.IsEnabled() {
+		module.suppressionList = suppression.NewMemory()
 	}
 
 	return module, nil
@@ -43,6 +105,9 @@ func NewModule(configFile string) (*Module, error) {
 func (m *Module) AddProvider(provider model.Provider) error {
 	providerName := provider.Name()
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Check if a provider with the same name already exists
 	if _, exists := m.providers[providerName]; exists {
 		return fmt.Errorf("provider with name '%s' is already registered", providerName)
@@ -59,8 +124,85 @@ func (m *Module) AddProvider(provider model.Provider) error {
 	return nil
 }
 
+// ReplaceProvider atomically swaps the registered provider that has the same name as the given one.
+// If the replaced provider was active, the new provider becomes active.
+func (m *Module) ReplaceProvider(provider model.Provider) error {
+	providerName := provider.Name()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.providers[providerName]; !exists {
+		return fmt.Errorf("provider '%s' not found", providerName)
+	}
+
+	m.providers[providerName] = provider
+
+	// The new implementation starts with a closed circuit and a full token bucket
+	delete(m.breakers, providerName)
+	delete(m.limiters, providerName)
+
+	if m.activeProvider != nil && m.activeProvider.Name() == providerName {
+		m.activeProvider = provider
+	}
+
+	return nil
+}
+
+// RemoveProvider unregisters the provider with the specified name.
+// If it was the active provider, the default provider, the first registered failover provider,
+// or otherwise the first remaining provider by name becomes active. The provider's circuit
+// breaker and rate limiter are dropped, so a provider added again under the name starts afresh.
+func (m *Module) RemoveProvider(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.providers[name]; !exists {
+		return fmt.Errorf("provider '%s' not found", name)
+	}
+
+	delete(m.providers, name)
+	delete(m.breakers, name)
+	delete(m.limiters, name)
+
+	if m.activeProvider != nil && m.activeProvider.Name() == name {
+		m.activeProvider = m.fallbackProvider()
+	}
+
+	return nil
+}
+
+// fallbackProvider picks a new active provider after the active one was removed.
+// It must be called with m.mu held.
+func (m *Module) fallbackProvider() model.Provider {
+	if provider, exists := m.providers[m.config.DefaultProvider]; exists {
+		return provider
+	}
+
+	for _, name := range m.config.Failover {
+		if provider, exists := m.providers[name]; exists {
+			return provider
+		}
+	}
+
+	// Sort the remaining names so the choice does not depend on map iteration order
+	names := make([]string, 0, len(m.providers))
+	for name := range m.providers {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	return m.providers[names[0]]
+}
+
 // SwitchProvider changes the active provider to the one with the specified name
 func (m *Module) SwitchProvider(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	provider, exists := m.providers[name]
 	if !exists {
 		return fmt.Errorf("provider '%s' not found", name)
@@ -72,6 +214,9 @@ func (m *Module) SwitchProvider(name string) error {
 
 // GetProvider returns a provider by name
 func (m *Module) GetProvider(name string) (model.Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	provider, exists := m.providers[name]
 	if !exists {
 		return nil, fmt.Errorf("provider '%s' not found", name)
@@ -82,6 +227,9 @@ func (m *Module) GetProvider(name string) (model.Provider, error) {
 
 // GetActiveProvider returns the currently active provider
 func (m *Module) GetActiveProvider() (model.Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.activeProvider == nil {
 		return nil, fmt.Errorf("no active provider set")
 	}
@@ -89,86 +237,185 @@ func (m *Module) GetActiveProvider() (model.Provider, error) {
 	return m.activeProvider, nil
 }
 
-// SendSMS sends an SMS message using the active provider with retry logic
+// SetRetryHook registers a function that is called before each retry, e.g. to log or count retries.
+// Passing nil removes the hook.
+func (m *Module) SetRetryHook(hook RetryHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retryHook = hook
+}
+
+// retryConfig builds the retry configuration used for attempts against a provider
+func (m *Module) retryConfig(providerName string) retry.Config {
+	m.mu.RLock()
+	hook := m.retryHook
+	m.mu.RUnlock()
+
+	retryConfig := retry.Config{
+		MaxAttempts:  m.config.RetryAttempts,
+		InitialDelay: m.config.RetryDelay,
+		MaxDelay:     m.config.GetRetryMaxDelay(),
+		Multiplier:   m.config.GetRetryMultiplier(),
+		Strategy:     retry.Strategy(m.config.RetryStrategy),
+	}
+	if hook != nil {
+		retryConfig.OnRetry = func(attempt int, err error, nextDelay time.Duration) {
+			hook(providerName, attempt, err, nextDelay)
+		}
+	}
+
+	return retryConfig
+}
+
+// SendSMS sends an SMS message using the active provider with retry logic.
+// If the active provider fails, the failover providers from the configuration are tried in order.
+// The first matching routing rule from the configuration replaces the active provider, and
+// req.Provider sends through a specific provider instead. Providers whose circuit breaker
+// is open are skipped without being called, and the configured rate limits are enforced
+// per provider and per recipient. Recipients on the suppression list get an error matching
+// ErrRecipientSuppressed unless the request category bypasses the list; recipients a provider
+// reports as unsubscribed are added to the list.
 func (m *Module) SendSMS(ctx context.Context, req model.SendSMSRequest) (model.SendSMSResponse, error) {
-	if m.activeProvider == nil {
-		return model.SendSMSResponse{}, fmt.Errorf("no active provider set")
+	// Pick the providers and sender; the chain is a snapshot so concurrent provider
+	// changes do not affect this send
+	route, chain, err := m.routeSMS(req)
+	if err != nil {
+		return model.SendSMSResponse{}, err
 	}
+	req.Message.From = route.Sender
 
-	// Validate the request
+	// Validate the request and normalize the recipient number
+	req.Region = m.region(req.Region)
 	if err := req.Validate(); err != nil {
 		return model.SendSMSResponse{}, err
 	}
 
-	// Create retry configuration
-	retryConfig := retry.Config{
-		MaxAttempts:  m.config.RetryAttempts,
-		InitialDelay: m.config.RetryDelay,
-		MaxDelay:     30 * time.Second, // Maximum delay between retries
-		Multiplier:   2.0,              // Exponential backoff multiplier
+	// Never text a recipient who opted out
+	if err := m.checkSuppression(ctx, req); err != nil {
+		return model.SendSMSResponse{}, err
 	}
 
-	// Initialize response variable
-	var response model.SendSMSResponse
+	// Stop floods to a single recipient before any provider is called
+	if err := m.acquireRecipientToken(req.Message.To); err != nil {
+		return model.SendSMSResponse{}, err
+	}
+	failFast := m.failFast(req.FailFast)
 
-	// Execute with retry
-	err := retry.Do(ctx, retryConfig, func() error {
-		var err error
-		response, err = m.activeProvider.SendSMS(ctx, req)
-		return err
+	// Initialize response variables
+	var response model.SendSMSResponse
+	var body string
+	var transliteration *model.Transliteration
+	var calls int
+
+	// Execute with retry against each provider in the failover chain
+	attempts, err := tryProviders(ctx, chain, func(provider model.Provider) error {
+		// Resolve the template for this provider and render the body
+		prepared, report, err := m.prepareSMS(req, provider.Name())
+		if err != nil {
+			return &requestError{err: err}
+		}
+		body, transliteration = prepared.Body, report
+
+		return retry.Do(ctx, m.retryConfig(provider.Name()), func() error {
+			return m.callProvider(ctx, provider.Name(), failFast, func() error {
+				var err error
+				calls++
+				response, err = provider.SendSMS(ctx, prepared)
+				return err
+			})
+		})
 	})
 
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return model.SendSMSResponse{}, reqErr.err
+	}
 	if err != nil {
-		return model.SendSMSResponse{}, fmt.Errorf("failed to send SMS after %d attempts: %w",
-			m.config.RetryAttempts, err)
+		m.suppressOptedOut(ctx, req.Message.To, attempts)
+		return model.SendSMSResponse{}, fmt.Errorf("failed to send SMS after %s: %w",
+			describeAttempts(calls, attempts), err)
 	}
 
 	// Ensure the provider field is set
 	if response.Provider == "" {
-		response.Provider = m.activeProvider.Name()
+		response.Provider = attempts[len(attempts)-1].Provider
 	}
+	response.Body = body
+	response.Route = route.Rule
+	response.Carrier = route.Carrier
+	response.Attempts = attempts
+
+	// Record how the body is encoded and how many parts it takes
+	segmentInfo := model.AnalyzeSMS(body)
+	response.Encoding = segmentInfo.Encoding
+	response.Segments = segmentInfo.Segments
+	response.Transliteration = transliteration
 
 	return response, nil
 }
 
-// SendVoiceCall initiates a voice call using the active provider with retry logic
+// SendVoiceCall initiates a voice call using the active provider with retry logic.
+// If the active provider fails, the failover providers from the configuration are tried in order.
+// Set req.Provider to call through a specific provider instead.
 func (m *Module) SendVoiceCall(ctx context.Context, req model.SendVoiceRequest) (model.SendVoiceResponse, error) {
-	if m.activeProvider == nil {
-		return model.SendVoiceResponse{}, fmt.Errorf("no active provider set")
+	// Snapshot the providers so concurrent provider changes do not affect this send
+	chain, err := m.sendChain(req.Provider)
+	if err != nil {
+		return model.SendVoiceResponse{}, err
 	}
 
-	// Validate the request
+	// Validate the request and normalize the recipient number
+	req.Region = m.region(req.Region)
 	if err := req.Validate(); err != nil {
 		return model.SendVoiceResponse{}, err
 	}
 
-	// Create retry configuration
-	retryConfig := retry.Config{
-		MaxAttempts:  m.config.RetryAttempts,
-		InitialDelay: m.config.RetryDelay,
-		MaxDelay:     30 * time.Second, // Maximum delay between retries
-		Multiplier:   2.0,              // Exponential backoff multiplier
+	// Stop floods to a single recipient before any provider is called
+	if err := m.acquireRecipientToken(req.Message.To); err != nil {
+		return model.SendVoiceResponse{}, err
 	}
+	failFast := m.failFast(req.FailFast)
 
-	// Initialize response variable
+	// Initialize response variables
 	var response model.SendVoiceResponse
-
-	// Execute with retry
-	err := retry.Do(ctx, retryConfig, func() error {
-		var err error
-		response, err = m.activeProvider.SendVoiceCall(ctx, req)
-		return err
+	var body string
+	var calls int
+
+	// Execute with retry against each provider in the failover chain
+	attempts, err := tryProviders(ctx, chain, func(provider model.Provider) error {
+		// Resolve the template for this provider and render the body
+		prepared, err := m.prepareVoice(req, provider.Name())
+		if err != nil {
+			return &requestError{err: err}
+		}
+		body = prepared.Body
+
+		return retry.Do(ctx, m.retryConfig(provider.Name()), func() error {
+			return m.callProvider(ctx, provider.Name(), failFast, func() error {
+				var err error
+				calls++
+				response, err = provider.SendVoiceCall(ctx, prepared)
+				return err
+			})
+		})
 	})
 
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return model.SendVoiceResponse{}, reqErr.err
+	}
 	if err != nil {
-		return model.SendVoiceResponse{}, fmt.Errorf("failed to send voice call after %d attempts: %w",
-			m.config.RetryAttempts, err)
+		return model.SendVoiceResponse{}, fmt.Errorf("failed to send voice call after %s: %w",
+			describeAttempts(calls, attempts), err)
 	}
 
 	// Ensure the provider field is set
 	if response.Provider == "" {
-		response.Provider = m.activeProvider.Name()
+		response.Provider = attempts[len(attempts)-1].Provider
 	}
+	response.Body = body
+	response.Attempts = attempts
 
 	return response, nil
 }
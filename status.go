@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-fork/sms/model"
+)
+
+// ErrStatusLookupNotSupported is matched by errors.Is when a provider cannot look up message status
+var ErrStatusLookupNotSupported = errors.New("message status lookup is not supported")
+
+// GetMessageStatus looks up the delivery status of a message sent through the named provider,
+// using the message ID from its SendSMSResponse. The provider must implement
+// model.StatusProvider; otherwise the error matches ErrStatusLookupNotSupported.
+// Lookups are not retried and do not go through the circuit breakers or rate limits.
+func (m *Module) GetMessageStatus(ctx context.Context, provider, messageID string) (model.MessageStatusResponse, error) {
+	if messageID == "" {
+		return model.MessageStatusResponse{}, fmt.Errorf("message ID is required")
+	}
+
+	p, err := m.GetProvider(provider)
+	if err != nil {
+		return model.MessageStatusResponse{}, err
+	}
+
+	statusProvider, ok := p.(model.StatusProvider)
+	if !ok {
+		return model.MessageStatusResponse{}, fmt.Errorf("provider '%s': %w", provider, ErrStatusLookupNotSupported)
+	}
+
+	resp, err := statusProvider.GetMessageStatus(ctx, messageID)
+	if err != nil {
+		return model.MessageStatusResponse{}, fmt.Errorf("failed to get message status from provider '%s': %w", provider, err)
+	}
+
+	if resp.Provider == "" {
+		resp.Provider = provider
+	}
+	if resp.MessageID == "" {
+		resp.MessageID = messageID
+	}
+	return resp, nil
+}
@@ -0,0 +1,195 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/suppression"
+	"github.com/go-fork/sms/webhook"
+)
+
+// ErrRecipientSuppressed is matched by errors.Is when a message is not sent because the
+// recipient is on the suppression list
+var ErrRecipientSuppressed = errors.New("recipient is suppressed")
+
+// ErrSuppressionDisabled is returned when a suppression list operation is called on a module without a list
+var ErrSuppressionDisabled = errors.New("suppression list is not configured")
+
+// SuppressedError is returned by SendSMS when the recipient opted out
+type SuppressedError struct {
+	// Recipient is the suppressed number
+	Recipient string
+
+	// Entry is the suppression list entry of the recipient
+	Entry suppression.Entry
+}
+
+// Error returns the error message
+func (e *SuppressedError) Error() string {
+	return fmt.Sprintf("recipient '%s' is suppressed (%s)", e.Recipient, e.Entry.Reason)
+}
+
+// Is reports whether target is ErrRecipientSuppressed
+func (e *SuppressedError) Is(target error) bool {
+	return target == ErrRecipientSuppressed
+}
+
+// SetSuppressionList replaces the suppression list checked before each SMS is sent.
+// A nil list turns suppression off.
+func (m *Module) SetSuppressionList(list suppression.List) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.suppressionList = list
+}
+
+// GetSuppressionList returns the suppression list, or nil if there is none
+func (m *Module) GetSuppressionList() suppression.List {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.suppressionList
+}
+
+// Suppress adds a number to the suppression list. The number is normalized like a recipient,
+// so that national and international forms match; numbers that cannot be normalized to
+// E.164 are rejected, since sends would never be checked against them.
+func (m *Module) Suppress(ctx context.Context, entry suppression.Entry) error {
+	list := m.GetSuppressionList()
+	if list == nil {
+		return ErrSuppressionDisabled
+	}
+
+	number, err := m.normalizeNumber(entry.Number)
+	if err != nil {
+		return fmt.Errorf("failed to suppress '%s': %w", entry.Number, err)
+	}
+
+	entry.Number = number
+	if err := list.Add(ctx, entry); err != nil {
+		return fmt.Errorf("failed to suppress '%s': %w", entry.Number, err)
+	}
+	return nil
+}
+
+// Unsuppress removes a number from the suppression list
+func (m *Module) Unsuppress(ctx context.Context, number string) error {
+	list := m.GetSuppressionList()
+	if list == nil {
+		return ErrSuppressionDisabled
+	}
+
+	normalized, err := m.normalizeNumber(number)
+	if err != nil {
+		return fmt.Errorf("failed to unsuppress '%s': %w", number, err)
+	}
+
+	if err := list.Remove(ctx, normalized); err != nil {
+		return fmt.Errorf("failed to unsuppress '%s': %w", normalized, err)
+	}
+	return nil
+}
+
+// OptOut suppresses the sender of an inbound message. It is a webhook.InboundFunc,
+// meant for opt-out keywords such as STOP. Senders that cannot be normalized to E.164
+// return an error, so that the webhook answers with a server error instead of losing the opt-out.
+func (m *Module) OptOut(ctx context.Context, msg model.InboundMessage) error {
+	return m.Suppress(ctx, suppression.Entry{
+		Number:   msg.From,
+		Reason:   suppression.ReasonOptOut,
+		Provider: msg.Provider,
+		Detail:   msg.Keyword(),
+	})
+}
+
+// OptIn lifts the suppression of the sender of an inbound message. It is a webhook.InboundFunc,
+// meant for opt-in keywords such as START.
+func (m *Module) OptIn(ctx context.Context, msg model.InboundMessage) error {
+	return m.Unsuppress(ctx, msg.From)
+}
+
+// RegisterOptOutKeywords routes the configured opt-out keywords (STOP, UNSUBSCRIBE, ...) to OptOut
+// and the opt-in keywords (START, ...) to OptIn, so that inbound messages feed the suppression list
+func (m *Module) RegisterOptOutKeywords(router *webhook.KeywordRouter) {
+	settings := m.config.GetSuppression()
+	for _, keyword := range settings.GetOptOutKeywords() {
+		router.On(keyword, m.OptOut)
+	}
+	for _, keyword := range settings.GetOptInKeywords() {
+		router.On(keyword, m.OptIn)
+	}
+}
+
+// checkSuppression returns a *SuppressedError when the recipient of a request is suppressed
+// and its category does not bypass the list. Lookup errors are returned, so that nothing
+// is sent when the list cannot be read.
+func (m *Module) checkSuppression(ctx context.Context, req model.SendSMSRequest) error {
+	list := m.GetSuppressionList()
+	if list == nil || m.bypassesSuppression(req.Category) {
+		return nil
+	}
+
+	entry, suppressed, err := list.Get(ctx, req.Message.To)
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if suppressed {
+		return &SuppressedError{Recipient: req.Message.To, Entry: entry}
+	}
+	return nil
+}
+
+// bypassesSuppression reports whether messages of a category are sent to suppressed numbers
+func (m *Module) bypassesSuppression(category model.MessageCategory) bool {
+	if category == "" {
+		return false
+	}
+	for _, bypass := range m.config.GetSuppression().GetBypassCategories() {
+		if strings.EqualFold(bypass, string(category)) {
+			return true
+		}
+	}
+	return false
+}
+
+// suppressOptedOut adds the recipient to the suppression list when a provider rejected
+// the message because the recipient unsubscribed. It is best effort: the send error
+// already reports the opt-out.
+func (m *Module) suppressOptedOut(ctx context.Context, to string, attempts []model.ProviderAttempt) {
+	if m.GetSuppressionList() == nil {
+		return
+	}
+
+	for _, attempt := range attempts {
+		var providerErr *model.ProviderError
+		if !errors.As(attempt.Err, &providerErr) || providerErr.Category != model.ErrorCategoryOptedOut {
+			continue
+		}
+
+		_ = m.Suppress(context.WithoutCancel(ctx), suppression.Entry{
+			Number:   to,
+			Reason:   suppression.ReasonProviderOptOut,
+			Provider: attempt.Provider,
+			Detail:   providerErr.Code,
+		})
+		return
+	}
+}
+
+// normalizeNumber returns a number in E.164. Numbers are read in the default region; digits
+// that cannot be read there are read as an international number without the leading "+",
+// the form in which eSMS and SpeedSMS report inbound senders (e.g. "84912345678").
+func (m *Module) normalizeNumber(number string) (string, error) {
+	parsed, err := model.ParsePhoneNumber(number, m.config.GetDefaultRegion())
+	if err != nil {
+		international, intlErr := model.ParsePhoneNumber("+"+strings.TrimSpace(number), "")
+		if intlErr != nil {
+			return "", err
+		}
+		parsed = international
+	}
+	return parsed.E164(), nil
+}
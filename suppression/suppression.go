@@ -0,0 +1,271 @@
+package suppression
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reason says why a number was suppressed
+type Reason string
+
+const (
+	// ReasonOptOut is used when the recipient replied with an opt-out keyword such as STOP
+	ReasonOptOut Reason = "opt_out"
+
+	// ReasonProviderOptOut is used when a provider rejected a message because the recipient unsubscribed
+	ReasonProviderOptOut Reason = "provider_opt_out"
+
+	// ReasonManual is used for numbers added by the application
+	ReasonManual Reason = "manual"
+)
+
+// Entry is a suppressed phone number
+type Entry struct {
+	// Number is the suppressed phone number, in the form the module sends to (E.164 when a region is known)
+	Number string `json:"number"`
+
+	// Reason says why the number was suppressed
+	Reason Reason `json:"reason"`
+
+	// Provider is the provider the opt-out came through (if any)
+	Provider string `json:"provider,omitempty"`
+
+	// Detail is the opt-out keyword or provider error code (if any)
+	Detail string `json:"detail,omitempty"`
+
+	// CreatedAt is when the number was suppressed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// List stores the phone numbers that must not receive messages.
+// Implementations must be safe for concurrent use.
+type List interface {
+	// Get returns the entry of a number, and whether the number is suppressed
+	Get(ctx context.Context, number string) (Entry, bool, error)
+
+	// Add suppresses a number, replacing its previous entry
+	Add(ctx context.Context, entry Entry) error
+
+	// Remove lifts the suppression of a number; removing a number that is not suppressed is not an error
+	Remove(ctx context.Context, number string) error
+}
+
+// Memory is a List kept in memory. It is lost when the process exits.
+type Memory struct {
+	// mu guards entries
+	mu sync.RWMutex
+
+	// entries maps numbers to their entries
+	entries map[string]Entry
+}
+
+// NewMemory creates an empty in-memory list
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]Entry),
+	}
+}
+
+// Get returns the entry of a number, and whether the number is suppressed
+func (l *Memory) Get(ctx context.Context, number string) (Entry, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entry, ok := l.entries[number]
+	return entry, ok, nil
+}
+
+// Add suppresses a number, replacing its previous entry. A zero CreatedAt is set to the current time.
+func (l *Memory) Add(ctx context.Context, entry Entry) error {
+	entry, err := prepare(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[entry.Number] = entry
+	return nil
+}
+
+// Remove lifts the suppression of a number
+func (l *Memory) Remove(ctx context.Context, number string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, number)
+	return nil
+}
+
+// Entries returns every entry, sorted by number
+func (l *Memory) Entries() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return sortedEntries(l.entries)
+}
+
+// File is a List kept in memory and saved to a JSON file after each change,
+// so that suppressions survive restarts. It is meant for a single process.
+type File struct {
+	// mu guards entries and writes to the file
+	mu sync.RWMutex
+
+	// path is the JSON file the entries are saved to
+	path string
+
+	// entries maps numbers to their entries
+	entries map[string]Entry
+}
+
+// NewFile creates a list saved to path, loading the entries already in the file.
+// The file is created on the first change if it does not exist.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("suppression list file path is required")
+	}
+
+	l := &File{
+		path:    path,
+		entries: make(map[string]Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppression list: %w", err)
+	}
+
+	var entries []Entry
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse suppression list '%s': %w", path, err)
+		}
+	}
+	for _, entry := range entries {
+		l.entries[entry.Number] = entry
+	}
+
+	return l, nil
+}
+
+// Get returns the entry of a number, and whether the number is suppressed
+func (l *File) Get(ctx context.Context, number string) (Entry, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entry, ok := l.entries[number]
+	return entry, ok, nil
+}
+
+// Add suppresses a number and saves the file. A zero CreatedAt is set to the current time.
+func (l *File) Add(ctx context.Context, entry Entry) error {
+	entry, err := prepare(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, existed := l.entries[entry.Number]
+	l.entries[entry.Number] = entry
+	if err := l.save(); err != nil {
+		// Keep memory and file in agreement
+		if existed {
+			l.entries[entry.Number] = previous
+		} else {
+			delete(l.entries, entry.Number)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove lifts the suppression of a number and saves the file
+func (l *File) Remove(ctx context.Context, number string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, existed := l.entries[number]
+	if !existed {
+		return nil
+	}
+
+	delete(l.entries, number)
+	if err := l.save(); err != nil {
+		l.entries[number] = previous
+		return err
+	}
+	return nil
+}
+
+// Entries returns every entry, sorted by number
+func (l *File) Entries() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return sortedEntries(l.entries)
+}
+
+// save writes the entries to a temporary file and renames it over the list,
+// so that a crash never leaves a partly written file
+func (l *File) save() error {
+	data, err := json.MarshalIndent(sortedEntries(l.entries), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode suppression list: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save suppression list: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save suppression list: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save suppression list: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to save suppression list: %w", err)
+	}
+	return nil
+}
+
+// prepare validates an entry and sets its creation time
+func prepare(entry Entry) (Entry, error) {
+	if entry.Number == "" {
+		return Entry{}, fmt.Errorf("suppression entry number is required")
+	}
+	if entry.Reason == "" {
+		entry.Reason = ReasonManual
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	return entry, nil
+}
+
+// sortedEntries returns the entries of a map sorted by number
+func sortedEntries(entries map[string]Entry) []Entry {
+	sorted := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+	return sorted
+}
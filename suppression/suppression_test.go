@@ -0,0 +1,126 @@
+package suppression
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testList runs the behavior every List shares
+func testList(t *testing.T, l List) {
+	ctx := context.Background()
+
+	if _, ok, err := l.Get(ctx, "+84912345678"); err != nil || ok {
+		t.Fatalf("Expected an empty list, got ok=%v err=%v", ok, err)
+	}
+
+	if err := l.Add(ctx, Entry{Number: "+84912345678", Reason: ReasonOptOut, Provider: "esms", Detail: "STOP"}); err != nil {
+		t.Fatalf("Expected the number to be added, got %v", err)
+	}
+	entry, ok, err := l.Get(ctx, "+84912345678")
+	if err != nil || !ok {
+		t.Fatalf("Expected the number to be suppressed, got ok=%v err=%v", ok, err)
+	}
+	if entry.Reason != ReasonOptOut || entry.Provider != "esms" || entry.Detail != "STOP" {
+		t.Errorf("Expected the stored entry, got %+v", entry)
+	}
+	if entry.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+
+	// Adding again replaces the entry
+	if err := l.Add(ctx, Entry{Number: "+84912345678"}); err != nil {
+		t.Fatalf("Expected the number to be added again, got %v", err)
+	}
+	if entry, _, _ := l.Get(ctx, "+84912345678"); entry.Reason != ReasonManual {
+		t.Errorf("Expected the entry to be replaced with a manual one, got %+v", entry)
+	}
+
+	if err := l.Add(ctx, Entry{}); err == nil {
+		t.Error("Expected an error for an entry without a number")
+	}
+
+	if err := l.Remove(ctx, "+84912345678"); err != nil {
+		t.Fatalf("Expected the number to be removed, got %v", err)
+	}
+	if _, ok, _ := l.Get(ctx, "+84912345678"); ok {
+		t.Error("Expected the number to be removed")
+	}
+	if err := l.Remove(ctx, "+84912345678"); err != nil {
+		t.Errorf("Expected removing an absent number to succeed, got %v", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testList(t, NewMemory())
+
+	l := NewMemory()
+	_ = l.Add(context.Background(), Entry{Number: "+84987654321"})
+	_ = l.Add(context.Background(), Entry{Number: "+14155552671"})
+	entries := l.Entries()
+	if len(entries) != 2 || entries[0].Number != "+14155552671" {
+		t.Errorf("Expected entries sorted by number, got %v", entries)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressed.json")
+
+	l, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected a missing file to give an empty list, got %v", err)
+	}
+	testList(t, l)
+
+	createdAt := time.Date(2024, 10, 1, 8, 30, 0, 0, time.UTC)
+	if err := l.Add(context.Background(), Entry{Number: "+84912345678", Reason: ReasonProviderOptOut, Provider: "twilio", Detail: "21610", CreatedAt: createdAt}); err != nil {
+		t.Fatalf("Expected the number to be added, got %v", err)
+	}
+
+	// A new list reads the saved entries
+	reloaded, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected the file to load, got %v", err)
+	}
+	entry, ok, _ := reloaded.Get(context.Background(), "+84912345678")
+	if !ok || entry.Reason != ReasonProviderOptOut || entry.Detail != "21610" || !entry.CreatedAt.Equal(createdAt) {
+		t.Errorf("Expected the saved entry, got %+v (ok=%v)", entry, ok)
+	}
+	if len(reloaded.Entries()) != 1 {
+		t.Errorf("Expected one entry, got %v", reloaded.Entries())
+	}
+
+	// No temporary files are left behind
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("Expected only the list file, got %d files", len(files))
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	if _, err := NewFile(""); err == nil {
+		t.Error("Expected an error for an empty path")
+	}
+
+	path := filepath.Join(t.TempDir(), "suppressed.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFile(path); err == nil {
+		t.Error("Expected an error for a corrupt file")
+	}
+
+	// Failed saves leave the list unchanged
+	l, err := NewFile(filepath.Join(t.TempDir(), "missing", "suppressed.json"))
+	if err != nil {
+		t.Fatalf("Expected the list to be created, got %v", err)
+	}
+	if err := l.Add(context.Background(), Entry{Number: "+84912345678"}); err == nil {
+		t.Fatal("Expected an error when the directory does not exist")
+	}
+	if _, ok, _ := l.Get(context.Background(), "+84912345678"); ok {
+		t.Error("Expected the number not to be suppressed after a failed save")
+	}
+}
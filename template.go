@@ -0,0 +1,155 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/go-fork/sms/model"
+)
+
+// resolveSMSTemplate picks the template for an SMS sent through the named provider:
+// the request template first, then the named registry template, then the provider's
+// sms_template, then the global default
+func (m *Module) resolveSMSTemplate(req model.SendSMSRequest, providerName string) (string, error) {
+	if req.Template != "" {
+		return req.Template, nil
+	}
+
+	if req.TemplateName != "" {
+		return m.config.GetTemplate(req.TemplateName, req.Locale)
+	}
+
+	if template := m.config.GetProviderSMSTemplate(providerName); template != "" {
+		return template, nil
+	}
+
+	return m.config.SMSTemplate, nil
+}
+
+// resolveVoiceTemplate picks the template for a voice call made through the named provider:
+// the request template first, then the named registry template, then the provider's
+// voice_template, then the global default
+func (m *Module) resolveVoiceTemplate(req model.SendVoiceRequest, providerName string) (string, error) {
+	if req.Template != "" {
+		return req.Template, nil
+	}
+
+	if req.TemplateName != "" {
+		return m.config.GetTemplate(req.TemplateName, req.Locale)
+	}
+
+	if template := m.config.GetProviderVoiceTemplate(providerName); template != "" {
+		return template, nil
+	}
+
+	return m.config.VoiceTemplate, nil
+}
+
+// ListTemplates returns the names of the registered templates with their available locales
+func (m *Module) ListTemplates() map[string][]string {
+	return m.config.ListTemplates()
+}
+
+// prepareSMS returns a copy of the request ready to be sent through the named provider.
+// Unless a body is already set, the template is resolved and rendered; the body is then
+// transliterated to GSM-7 if enabled. The transliteration report is nil if it was not applied.
+func (m *Module) prepareSMS(req model.SendSMSRequest, providerName string) (model.SendSMSRequest, *model.Transliteration, error) {
+	if req.Body == "" {
+		template, err := m.resolveSMSTemplate(req, providerName)
+		if err != nil {
+			return req, nil, err
+		}
+
+		req.Template = template
+		req.Body, err = m.render(req.Message, req.Template, req.Data, req.Strict)
+		if err != nil {
+			return req, nil, err
+		}
+
+		if req.Body == "" {
+			return req, nil, fmt.Errorf("empty message body after rendering template")
+		}
+	}
+
+	if !m.shouldTransliterate(req, providerName) {
+		return req, nil, nil
+	}
+
+	body, report := model.TransliterateGSM7(req.Body)
+	req.Body = body
+
+	return req, &report, nil
+}
+
+// shouldTransliterate decides whether an SMS body is transliterated to GSM-7:
+// the request setting first, then the provider's, then the global one
+func (m *Module) shouldTransliterate(req model.SendSMSRequest, providerName string) bool {
+	if req.Transliterate != nil {
+		return *req.Transliterate
+	}
+
+	return m.config.GetTransliterate(providerName)
+}
+
+// prepareVoice returns a copy of the request with its template resolved and body rendered
+// for the named provider. A body that is already set is left untouched.
+func (m *Module) prepareVoice(req model.SendVoiceRequest, providerName string) (model.SendVoiceRequest, error) {
+	if req.Body != "" {
+		return req, nil
+	}
+
+	template, err := m.resolveVoiceTemplate(req, providerName)
+	if err != nil {
+		return req, err
+	}
+
+	req.Template = template
+	req.Body, err = m.render(req.Message, req.Template, req.Data, req.Strict)
+	if err != nil {
+		return req, err
+	}
+
+	if req.Body == "" {
+		return req, fmt.Errorf("empty message text after rendering template")
+	}
+
+	return req, nil
+}
+
+// render renders a template for a message, in strict mode if the request or configuration asks for it
+func (m *Module) render(message model.Message, template string, data map[string]interface{}, strict bool) (string, error) {
+	if strict || m.config.StrictTemplates {
+		return message.RenderStrict(template, data)
+	}
+
+	return message.Render(template, data), nil
+}
+
+// RenderSMS returns the message body the active provider would send for the request
+func (m *Module) RenderSMS(req model.SendSMSRequest) (string, error) {
+	provider, err := m.GetActiveProvider()
+	if err != nil {
+		return "", err
+	}
+
+	prepared, _, err := m.prepareSMS(req, provider.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return prepared.Body, nil
+}
+
+// RenderVoice returns the voice script the active provider would use for the request
+func (m *Module) RenderVoice(req model.SendVoiceRequest) (string, error) {
+	provider, err := m.GetActiveProvider()
+	if err != nil {
+		return "", err
+	}
+
+	prepared, err := m.prepareVoice(req, provider.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return prepared.Body, nil
+}
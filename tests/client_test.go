@@ -3,13 +3,19 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-fork/sms/client"
 	"github.com/go-fork/sms/config"
+	"github.com/go-fork/sms/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -40,21 +46,75 @@ func TestClientCreation(t *testing.T) {
 			},
 			expectError: false, // Should use default timeout
 		},
+		{
+			name: "Proxy and connection settings",
+			config: &config.Config{
+				HTTPTimeout: 10 * time.Second,
+				HTTP: config.HTTPConfig{
+					Proxy:           "http://proxy.example.com:3128",
+					MaxConnsPerHost: 10,
+					TLS:             config.TLSConfig{MinVersion: "1.2"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Invalid proxy URL",
+			config: &config.Config{
+				HTTPTimeout: 10 * time.Second,
+				HTTP:        config.HTTPConfig{Proxy: "proxy.example.com"},
+			},
+			expectError: true,
+		},
+		{
+			name: "Missing CA file",
+			config: &config.Config{
+				HTTPTimeout: 10 * time.Second,
+				HTTP:        config.HTTPConfig{TLS: config.TLSConfig{CAFile: "/nonexistent/ca.pem"}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := client.NewClient(tt.config)
+			c, err := client.NewClient(tt.config)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, c)
+				return
+			}
+			require.NoError(t, err)
 			assert.NotNil(t, c, "Client should not be nil")
 		})
 	}
 }
 
+// minimalConfig implements config.ConfigProvider without the optional HTTPConfigProvider
+type minimalConfig struct{}
+
+func (minimalConfig) GetHTTPTimeout() time.Duration                            { return 5 * time.Second }
+func (minimalConfig) GetRetryAttempts() int                                    { return 1 }
+func (minimalConfig) GetRetryDelay() time.Duration                             { return time.Second }
+func (minimalConfig) GetDefaultProvider() string                               { return "" }
+func (minimalConfig) GetSMSTemplate() string                                   { return "" }
+func (minimalConfig) GetVoiceTemplate() string                                 { return "" }
+func (minimalConfig) Validate() error                                          { return nil }
+func (minimalConfig) GetProviderConfig(string) (map[string]interface{}, error) { return nil, nil }
+
+// TestClientCustomConfigProvider tests creating a client from a configuration without HTTP settings
+func TestClientCustomConfigProvider(t *testing.T) {
+	c, err := client.NewClient(minimalConfig{})
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
 // TestClientHeaders tests setting headers on the client
 func TestClientHeaders(t *testing.T) {
-	c := client.NewClient(&config.Config{
+	c, err := client.NewClient(&config.Config{
 		HTTPTimeout: 5 * time.Second,
 	})
+	require.NoError(t, err)
 
 	// Test setting a single header
 	c.SetHeader("X-Test-Header", "test-value")
@@ -81,9 +141,10 @@ func TestClientHeaders(t *testing.T) {
 
 // TestClientMethods tests the different HTTP methods
 func TestClientMethods(t *testing.T) {
-	c := client.NewClient(&config.Config{
+	c, err := client.NewClient(&config.Config{
 		HTTPTimeout: 5 * time.Second,
 	})
+	require.NoError(t, err)
 
 	// Create a test server that validates the HTTP method
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -120,9 +181,10 @@ func TestClientMethods(t *testing.T) {
 
 // TestClientTimeout tests that the client respects timeout settings
 func TestClientTimeout(t *testing.T) {
-	c := client.NewClient(&config.Config{
+	c, err := client.NewClient(&config.Config{
 		HTTPTimeout: 100 * time.Millisecond, // Very short timeout
 	})
+	require.NoError(t, err)
 
 	// Create a test server that delays response
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -132,16 +194,17 @@ func TestClientTimeout(t *testing.T) {
 	defer server.Close()
 
 	// The request should timeout
-	_, err := c.Get(context.Background(), server.URL)
+	_, err = c.Get(context.Background(), server.URL)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "timeout")
 }
 
 // TestClientProcessResponse tests the ProcessResponse helper
 func TestClientProcessResponse(t *testing.T) {
-	c := client.NewClient(&config.Config{
+	c, err := client.NewClient(&config.Config{
 		HTTPTimeout: 5 * time.Second,
 	})
+	require.NoError(t, err)
 
 	tests := []struct {
 		name         string
@@ -194,9 +257,10 @@ func TestClientProcessResponse(t *testing.T) {
 
 // TestClientBaseURL tests setting the base URL
 func TestClientBaseURL(t *testing.T) {
-	c := client.NewClient(&config.Config{
+	c, err := client.NewClient(&config.Config{
 		HTTPTimeout: 5 * time.Second,
 	})
+	require.NoError(t, err)
 
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -211,3 +275,111 @@ func TestClientBaseURL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode())
 }
+
+// TestClientRetryAfter tests reading Retry-After from error responses
+func TestClientRetryAfter(t *testing.T) {
+	c, err := client.NewClient(&config.Config{
+		HTTPTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	resp, err := c.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	_, err = c.ProcessResponse(resp, nil)
+	var httpErr *retry.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	assert.Equal(t, 2*time.Second, httpErr.RetryAfter)
+	assert.True(t, retry.IsRetriable(err))
+}
+
+// TestParseRetryAfter tests parsing Retry-After header values
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, client.ParseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, client.ParseRetryAfter("Mon, 01 Jan 2024 12:00:30 GMT", now))
+	assert.Equal(t, time.Duration(0), client.ParseRetryAfter("Mon, 01 Jan 2024 11:59:00 GMT", now))
+	assert.Equal(t, time.Duration(0), client.ParseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), client.ParseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), client.ParseRetryAfter("soon", now))
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestClientOptions tests injecting an http.Client or RoundTripper
+func TestClientOptions(t *testing.T) {
+	t.Run("With transport", func(t *testing.T) {
+		var requested string
+		transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requested = r.URL.String()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(`{"ok": true}`)),
+				Request:    r,
+			}, nil
+		})
+
+		c, err := client.NewClient(&config.Config{HTTPTimeout: 5 * time.Second}, client.WithTransport(transport))
+		require.NoError(t, err)
+
+		resp, err := c.Get(context.Background(), "https://api.example.com/status")
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.example.com/status", requested)
+		assert.Equal(t, `{"ok": true}`, resp.String())
+	})
+
+	t.Run("With HTTP client", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		// The test server's client trusts its self-signed certificate
+		c, err := client.NewClient(nil, client.WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+
+		resp, err := c.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+	})
+
+	t.Run("With configured CA file", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+		// Without the CA the certificate is rejected
+		c, err := client.NewClient(&config.Config{HTTPTimeout: 5 * time.Second})
+		require.NoError(t, err)
+		_, err = c.Get(context.Background(), server.URL)
+		assert.Error(t, err)
+
+		c, err = client.NewClient(&config.Config{
+			HTTPTimeout: 5 * time.Second,
+			HTTP:        config.HTTPConfig{TLS: config.TLSConfig{CAFile: caFile}},
+		})
+		require.NoError(t, err)
+		resp, err := c.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+	})
+}
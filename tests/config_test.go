@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-fork/sms/config"
+	"github.com/go-fork/sms/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -199,6 +200,156 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Invalid retry max delay below retry delay",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RetryMaxDelay:   100 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Invalid retry multiplier",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RetryMultiplier: 0.5,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Unknown retry strategy",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RetryStrategy:   "fibonacci",
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Valid retry backoff settings",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RetryMaxDelay:   10 * time.Second,
+				RetryMultiplier: 1.5,
+				RetryStrategy:   "full_jitter",
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Invalid circuit breaker failure threshold",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				CircuitBreaker:  config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1.5},
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Rate limit for unknown provider",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RateLimits: config.RateLimitConfig{
+					Providers: map[string]config.ProviderRateLimit{"unknown": {Rate: 10}},
+				},
+				SMSTemplate:   "Your message is {message}",
+				VoiceTemplate: "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Recipient rate limit without period",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				RateLimits: config.RateLimitConfig{
+					Recipient: config.RecipientRateLimit{Limit: 5},
+				},
+				SMSTemplate:   "Your message is {message}",
+				VoiceTemplate: "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Invalid HTTP proxy URL",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				HTTP:            config.HTTPConfig{Proxy: "://proxy"},
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "Valid zero retry attempts with zero retry delay",
 			config: &config.Config{
@@ -276,6 +427,96 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Failover provider not in providers",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+				Failover: []string{"test_provider", "non_existent"},
+			},
+			expectError: true,
+		},
+		{
+			name: "Route provider not in providers",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+				Routes: []config.RouteRule{{Name: "vietnam", Prefixes: []string{"+84"}, Provider: "non_existent"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "Route without name",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+				Routes: []config.RouteRule{{Prefixes: []string{"+84"}, Provider: "test_provider"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "Unsupported default region",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+				DefaultRegion: "XX",
+			},
+			expectError: true,
+		},
+		{
+			name: "Route with unknown carrier",
+			config: &config.Config{
+				DefaultProvider: "test_provider",
+				HTTPTimeout:     10 * time.Second,
+				RetryAttempts:   3,
+				RetryDelay:      500 * time.Millisecond,
+				SMSTemplate:     "Your message is {message}",
+				VoiceTemplate:   "Your message is {message}",
+				Providers: map[string]interface{}{
+					"test_provider": map[string]interface{}{
+						"api_key": "test_key",
+					},
+				},
+				Routes: []config.RouteRule{{Name: "carrier", Carriers: []string{"unknown"}, Provider: "test_provider"}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -354,6 +595,199 @@ providers:
 	assert.Equal(t, config.DefaultHTTPTimeout, cfg.HTTPTimeout)
 	assert.Equal(t, config.DefaultRetryAttempts, cfg.RetryAttempts)
 	assert.Equal(t, config.DefaultRetryDelay, cfg.RetryDelay)
+	assert.Equal(t, config.DefaultRetryMaxDelay, cfg.RetryMaxDelay)
+	assert.Equal(t, config.DefaultRetryMultiplier, cfg.RetryMultiplier)
 	assert.Equal(t, config.DefaultSMSTemplate, cfg.SMSTemplate)
 	assert.Equal(t, config.DefaultVoiceTemplate, cfg.VoiceTemplate)
 }
+
+// TestSuppressionConfig tests loading the suppression list settings and their defaults
+func TestSuppressionConfig(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: test_provider
+suppression:
+  file: /tmp/suppressed.json
+  opt_out_keywords: [STOP, huy]
+providers:
+  test_provider:
+    api_key: test_key
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+
+	settings := cfg.GetSuppression()
+	assert.True(t, settings.IsEnabled())
+	assert.Equal(t, "/tmp/suppressed.json", settings.File)
+	assert.Equal(t, []string{"STOP", "huy"}, settings.GetOptOutKeywords())
+	assert.Equal(t, config.DefaultOptInKeywords, settings.GetOptInKeywords())
+	assert.Equal(t, config.DefaultSuppressionBypassCategories, settings.GetBypassCategories())
+
+	// The list is off unless enabled or given a file
+	assert.False(t, config.SuppressionConfig{}.IsEnabled())
+	assert.True(t, config.SuppressionConfig{Enabled: true}.IsEnabled())
+}
+
+// TestTemplateRegistry tests loading named templates and resolving their locale variants
+func TestTemplateRegistry(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: test_provider
+default_locale: en
+templates:
+  otp:
+    vi: "Ma OTP cua ban la {otp}"
+    en: "Your OTP is {otp}"
+  welcome:
+    vi-VN: "Chao mung {name}"
+
+providers:
+  test_provider:
+    api_key: test_key
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		template    string
+		locale      string
+		expected    string
+		expectError bool
+	}{
+		{name: "Exact locale", template: "otp", locale: "vi", expected: "Ma OTP cua ban la {otp}"},
+		{name: "Region falls back to language", template: "otp", locale: "vi-VN", expected: "Ma OTP cua ban la {otp}"},
+		{name: "Unknown locale falls back to default", template: "otp", locale: "fr", expected: "Your OTP is {otp}"},
+		{name: "Empty locale uses default", template: "otp", locale: "", expected: "Your OTP is {otp}"},
+		{name: "Locale is case insensitive", template: "welcome", locale: "vi_vn", expected: "Chao mung {name}"},
+		{name: "No matching variant", template: "welcome", locale: "en", expectError: true},
+		{name: "Unknown template", template: "missing", locale: "en", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := cfg.GetTemplate(tt.template, tt.locale)
+			if tt.expectError {
+				assert.ErrorIs(t, err, config.ErrTemplateNotFound)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+		})
+	}
+
+	assert.Equal(t, map[string][]string{
+		"otp":     {"en", "vi"},
+		"welcome": {"vi-vn"},
+	}, cfg.ListTemplates())
+
+	// Templates with placeholders that can never resolve are rejected at load time
+	invalidFile, err := createTempConfig(`
+default_provider: test_provider
+templates:
+  otp:
+    en: "Your OTP is {otp"
+
+providers:
+  test_provider:
+    api_key: test_key
+`)
+	require.NoError(t, err)
+	defer os.Remove(invalidFile)
+
+	_, err = config.LoadConfig(invalidFile)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid template 'otp'")
+
+	// Configurations built in code are normalized like those loaded from a file
+	codeConfig := &config.Config{
+		DefaultProvider: "test_provider",
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		DefaultLocale:   "en-US",
+		Templates: map[string]map[string]string{
+			"OrderShipped": {"en-US": "Order {order_id} shipped", "vi_VN": "Don hang {order_id} da giao"},
+		},
+		Providers: map[string]interface{}{"test_provider": map[string]interface{}{"api_key": "test_key"}},
+	}
+	require.NoError(t, codeConfig.Validate())
+
+	text, err := codeConfig.GetTemplate("OrderShipped", "en-US")
+	require.NoError(t, err)
+	assert.Equal(t, "Order {order_id} shipped", text)
+
+	text, err = codeConfig.GetTemplate("ordershipped", "vi-VN")
+	require.NoError(t, err)
+	assert.Equal(t, "Don hang {order_id} da giao", text)
+
+	text, err = codeConfig.GetTemplate("ORDERSHIPPED", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Order {order_id} shipped", text)
+	assert.Equal(t, map[string][]string{"ordershipped": {"en-us", "vi-vn"}}, codeConfig.ListTemplates())
+
+	// Names that only differ in case are ambiguous
+	codeConfig.Templates = map[string]map[string]string{"OTP": {"en": "a {otp}"}, "otp": {"en": "b {otp}"}}
+	assert.Error(t, codeConfig.Validate())
+}
+
+// TestRouteMatching tests matching messages against routing rules
+func TestRouteMatching(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: twilio
+routes:
+  - name: viettel
+    prefixes: ["+8486", "+8496", "+8497", "+8498"]
+    provider: speedsms
+  - name: vietnam
+    country_codes: [84]
+    provider: esms
+    sender: MYBRAND
+  - name: billing
+    by: [Billing]
+    provider: esms
+
+providers:
+  twilio:
+    api_key: key1
+  esms:
+    api_key: key2
+  speedsms:
+    api_key: key3
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.GetRoutes(), 3)
+
+	tests := []struct {
+		name     string
+		to       string
+		by       string
+		expected string
+	}{
+		{name: "Prefix", to: "+84961234567", expected: "viettel"},
+		{name: "Prefix with formatting", to: "0084 96 123 4567", expected: "viettel"},
+		{name: "Country code", to: "+84912345678", expected: "vietnam"},
+		{name: "Application", to: "+14155550100", by: "billing", expected: "billing"},
+		{name: "No match", to: "+14155550100", by: "marketing", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := cfg.MatchRoute(model.Message{To: tt.to, By: tt.by})
+			assert.Equal(t, tt.expected != "", ok)
+			assert.Equal(t, tt.expected, rule.Name)
+		})
+	}
+
+	rule, _ := cfg.MatchRoute(model.Message{To: "+84912345678"})
+	assert.Equal(t, "esms", rule.Provider)
+	assert.Equal(t, "MYBRAND", rule.Sender)
+}
@@ -1,10 +1,15 @@
 package tests
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-fork/sms/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestMessageCreation tests creating message models
@@ -195,6 +200,189 @@ func TestTemplateRendering(t *testing.T) {
 	}
 }
 
+// TestStrictTemplateRendering tests strict rendering and literal brace escapes
+func TestStrictTemplateRendering(t *testing.T) {
+	message := model.Message{From: "Sender", To: "+1234567890", By: "TestApp"}
+
+	// Escaped braces are rendered literally in both modes
+	template := "Use {{code}} as the {by} code: {code}"
+	data := map[string]interface{}{"code": "123456"}
+	assert.Equal(t, "Use {code} as the TestApp code: 123456", message.Render(template, data))
+
+	result, err := message.RenderStrict(template, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Use {code} as the TestApp code: 123456", result)
+
+	// Strict mode lists every unresolved placeholder once
+	_, err = message.RenderStrict("Hi {name}, your OTP is {otp}. Again: {otp}", nil)
+	var missingErr *model.MissingVariablesError
+	assert.True(t, errors.As(err, &missingErr))
+	assert.Equal(t, []string{"name", "otp"}, missingErr.Variables)
+	assert.Contains(t, err.Error(), "name, otp")
+
+	// Lenient mode keeps unresolved placeholders in the output
+	assert.Equal(t, "Your OTP is {otp}", message.Render("Your OTP is {otp}", nil))
+}
+
+// TestTemplateFilters tests filters, defaults and conditional sections
+func TestTemplateFilters(t *testing.T) {
+	message := model.Message{From: "Sender", To: "+1234567890", By: "TestApp"}
+	expiresAt := time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		data     map[string]interface{}
+		expected string
+	}{
+		{name: "Upper", template: "Hi {name|upper}", data: map[string]interface{}{"name": "an"}, expected: "Hi AN"},
+		{name: "Title", template: "Hi {name|title}", data: map[string]interface{}{"name": "nguyen van an"}, expected: "Hi Nguyen Van An"},
+		{name: "Chained filters", template: "{name|trim|lower}", data: map[string]interface{}{"name": "  AN "}, expected: "an"},
+		{name: "Default for missing value", template: `Hi {name|default:"customer"}`, data: nil, expected: "Hi customer"},
+		{name: "Default for empty value", template: "Hi {name|default:customer|upper}", data: map[string]interface{}{"name": ""}, expected: "Hi CUSTOMER"},
+		{name: "Default keeps present value", template: `Hi {name|default:"customer"}`, data: map[string]interface{}{"name": "An"}, expected: "Hi An"},
+		{name: "VND currency", template: "Total {amount|currency:VND}", data: map[string]interface{}{"amount": 1500000}, expected: "Total 1.500.000 VND"},
+		{name: "USD currency", template: "Total {amount|currency:usd}", data: map[string]interface{}{"amount": 1234.5}, expected: "Total 1,234.50 USD"},
+		{name: "Number", template: "{count|number}", data: map[string]interface{}{"count": "-1234567"}, expected: "-1,234,567"},
+		{name: "Time layout", template: "Expires at {expires_at|time:15:04}", data: map[string]interface{}{"expires_at": expiresAt}, expected: "Expires at 09:30"},
+		{name: "Time default layout", template: "{expires_at|time}", data: map[string]interface{}{"expires_at": "2024-05-17T09:30:00Z"}, expected: "09:30 17/05/2024"},
+		{name: "Section shown", template: "Hi{?promo} - use {promo}{/promo}", data: map[string]interface{}{"promo": "SALE10"}, expected: "Hi - use SALE10"},
+		{name: "Section hidden", template: "Hi{?promo} - use {promo}{/promo}", data: map[string]interface{}{"promo": ""}, expected: "Hi"},
+		{name: "Inverted section", template: "{!promo}No promo today{/promo}", data: nil, expected: "No promo today"},
+		{name: "Unclosed section kept as text", template: "Hi {?promo}code", data: nil, expected: "Hi {?promo}code"},
+		{name: "Unclosed section keeps inner sections", template: "Hi {?promo}{?code}use {code}{/code}", data: map[string]interface{}{"code": "SALE10"}, expected: "Hi {?promo}use SALE10"},
+		{name: "Filter error keeps placeholder", template: "Total {amount|currency:VND}", data: map[string]interface{}{"amount": "abc"}, expected: "Total {amount|currency:VND}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, message.Render(tt.template, tt.data))
+		})
+	}
+
+	// Strict rendering reports filter errors and ignores variables in hidden sections
+	_, err := message.RenderStrict("Total {amount|currency:VND}", map[string]interface{}{"amount": "abc"})
+	assert.Error(t, err)
+
+	result, err := message.RenderStrict("Hi{?promo} {code}{/promo}", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi", result)
+
+	_, err = message.RenderStrict("Hi {?promo}code", nil)
+	assert.Error(t, err)
+
+	// Custom filters can be registered
+	err = model.RegisterTemplateFilter("mask", func(value interface{}, _ string) (interface{}, error) {
+		text := fmt.Sprintf("%v", value)
+		return strings.Repeat("*", len(text)-3) + text[len(text)-3:], nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Card *****123", message.Render("Card {card|mask}", map[string]interface{}{"card": "12345123"}))
+	assert.Error(t, model.RegisterTemplateFilter("default", nil))
+}
+
+// TestAnalyzeSMS tests encoding detection and segment counting
+func TestAnalyzeSMS(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		encoding   model.Encoding
+		characters int
+		units      int
+		segments   int
+		unicode    []string
+	}{
+		{name: "Empty body", body: "", encoding: model.EncodingGSM7},
+		{name: "Plain GSM-7", body: "Ma OTP cua ban la 123456", encoding: model.EncodingGSM7, characters: 24, units: 24, segments: 1},
+		{name: "Extension characters take two septets", body: "Price: 10€ [promo]", encoding: model.EncodingGSM7, characters: 18, units: 21, segments: 1},
+		{name: "Single GSM-7 segment limit", body: strings.Repeat("a", 160), encoding: model.EncodingGSM7, characters: 160, units: 160, segments: 1},
+		{name: "Concatenated GSM-7", body: strings.Repeat("a", 161), encoding: model.EncodingGSM7, characters: 161, units: 161, segments: 2},
+		{name: "Extension character is not split", body: strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), encoding: model.EncodingGSM7, characters: 163, units: 164, segments: 2},
+		{
+			name:       "Vietnamese diacritics force UCS-2",
+			body:       "Mã OTP của bạn là 123456",
+			encoding:   model.EncodingUCS2,
+			characters: 24,
+			units:      24,
+			segments:   1,
+			unicode:    []string{"ã", "ủ", "ạ"},
+		},
+		{name: "Concatenated UCS-2", body: strings.Repeat("ạ", 71), encoding: model.EncodingUCS2, characters: 71, units: 71, segments: 2, unicode: []string{"ạ"}},
+		{name: "Surrogate pairs take two units", body: "OK 👍", encoding: model.EncodingUCS2, characters: 4, units: 5, segments: 1, unicode: []string{"👍"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := model.AnalyzeSMS(tt.body)
+			assert.Equal(t, tt.encoding, info.Encoding)
+			assert.Equal(t, tt.characters, info.Characters)
+			assert.Equal(t, tt.units, info.Units)
+			assert.Equal(t, tt.segments, info.Segments)
+			assert.Equal(t, tt.unicode, info.UnicodeCharacters)
+			assert.Equal(t, tt.encoding == model.EncodingGSM7, model.IsGSM7(tt.body))
+		})
+	}
+}
+
+// TestTransliterateGSM7 tests rewriting text to the GSM-7 alphabet
+func TestTransliterateGSM7(t *testing.T) {
+	text, report := model.TransliterateGSM7("Mã OTP của bạn là 123456. Đừng chia sẻ mã!")
+	assert.Equal(t, "Ma OTP cua ban la 123456. Dung chia se ma!", text)
+	assert.True(t, model.IsGSM7(text))
+	assert.True(t, report.Changed())
+	assert.Empty(t, report.Unmapped)
+	assert.Equal(t, model.CharacterReplacement{From: "ã", To: "a", Count: 2}, report.Replacements[0])
+
+	// Decomposed (NFD) text is normalized before the lookup
+	text, report = model.TransliterateGSM7("Ma\u0303 OTP cu\u0309a ba\u0323n")
+	assert.Equal(t, "Ma OTP cua ban", text)
+	assert.True(t, model.IsGSM7(text))
+	assert.Empty(t, report.Unmapped)
+	assert.Equal(t, model.CharacterReplacement{From: "ã", To: "a", Count: 1}, report.Replacements[0])
+
+	// Other characters are mapped to GSM-7 equivalents
+	text, _ = model.TransliterateGSM7("“Sale” – 50%… ends soon")
+	assert.Equal(t, "\"Sale\" - 50%... ends soon", text)
+
+	// Characters without a replacement are kept and reported
+	text, report = model.TransliterateGSM7("Thanks 👍")
+	assert.Equal(t, "Thanks 👍", text)
+	assert.False(t, report.Changed())
+	assert.Equal(t, []string{"👍"}, report.Unmapped)
+}
+
+// TestTemplateValidation tests detecting placeholders that can never resolve
+func TestTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		expectError bool
+	}{
+		{name: "Valid template", template: "Hello {name}, your code is {code}", expectError: false},
+		{name: "No placeholders", template: "Hello world", expectError: false},
+		{name: "Escaped braces", template: "Literal {{braces}} and {name}", expectError: false},
+		{name: "Unclosed placeholder", template: "Hello {name", expectError: true},
+		{name: "Unmatched closing brace", template: "Hello name}", expectError: true},
+		{name: "Nested placeholder", template: "Hello {na{me}}", expectError: true},
+		{name: "Empty placeholder", template: "Hello { }", expectError: true},
+		{name: "Filters and sections", template: "{?promo}{promo|upper}{/promo}{name|default:\"you\"}", expectError: false},
+		{name: "Unknown filter", template: "Hello {name|shout}", expectError: true},
+		{name: "Unclosed section", template: "Hello {?promo}{promo}", expectError: true},
+		{name: "Mismatched section end", template: "{?promo}{promo}{/code}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := model.ValidateTemplate(tt.template)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 // TestMessageValidation tests the message validation functions
 func TestPhoneNumberValidation(t *testing.T) {
 	tests := []struct {
@@ -262,6 +450,107 @@ func TestPhoneNumberValidation(t *testing.T) {
 	}
 }
 
+// TestParsePhoneNumber tests parsing and normalizing phone numbers to E.164
+func TestParsePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		number      string
+		region      string
+		expected    string
+		countryCode string
+		national    string
+		expectError bool
+	}{
+		{name: "Vietnamese national format", number: "0912345678", region: "VN", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "Vietnamese with formatting", number: "091 234-5678", region: "vn", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "Vietnamese without trunk prefix", number: "912345678", region: "VN", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "Country code without plus", number: "84912345678", region: "VN", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "International format", number: "+84 912 345 678", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "International with trunk prefix", number: "+84 0912345678", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "International 00 prefix", number: "0084912345678", region: "US", expected: "+84912345678", countryCode: "84", national: "912345678"},
+		{name: "North American number", number: "+1 (415) 555-2671", expected: "+14155552671", countryCode: "1", national: "4155552671"},
+		{name: "North American national format", number: "415-555-2671", region: "US", expected: "+14155552671", countryCode: "1", national: "4155552671"},
+		{name: "Three digit country code", number: "+85512345678", expected: "+85512345678", countryCode: "855", national: "12345678"},
+		{name: "Country without length rules", number: "+351912345678", expected: "+351912345678", countryCode: "351", national: "912345678"},
+		{name: "Vietnamese number too short", number: "+8491234567", expectError: true},
+		{name: "Trunk prefix on a short number", number: "+84 091234567", expectError: true},
+		{name: "Vietnamese national number too long", number: "091234567890", region: "VN", expectError: true},
+		{name: "North American number too short", number: "+1234567890", expectError: true},
+		{name: "National format without region", number: "0912345678", expectError: true},
+		{name: "Unsupported region", number: "0912345678", region: "XX", expectError: true},
+		{name: "Too many digits", number: "+8491234567890123", expectError: true},
+		{name: "Contains letters", number: "+84912abc678", expectError: true},
+		{name: "Empty string", number: "", region: "VN", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := model.ParsePhoneNumber(tt.number, tt.region)
+			if tt.expectError {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, model.ErrInvalidPhoneNumber))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, number.E164())
+			assert.Equal(t, tt.countryCode, number.CountryCode)
+			assert.Equal(t, tt.national, number.NationalNumber)
+		})
+	}
+}
+
+// TestDetectCarrier tests detecting Vietnamese carriers from number prefixes
+func TestDetectCarrier(t *testing.T) {
+	tests := []struct {
+		number   string
+		expected model.Carrier
+	}{
+		{number: "0961234567", expected: model.CarrierViettel},
+		{number: "+84 32 123 4567", expected: model.CarrierViettel},
+		{number: "0901234567", expected: model.CarrierMobifone},
+		{number: "0912345678", expected: model.CarrierVinaphone},
+		{number: "84921234567", expected: model.CarrierVietnamobile},
+		{number: "0991234567", expected: model.CarrierGmobile},
+		{number: "0871234567", expected: model.CarrierItel},
+		{number: "0551234567", expected: model.CarrierReddi},
+		{number: "02412345678", expected: model.CarrierUnknown},
+		{number: "+14155552671", expected: model.CarrierUnknown},
+		{number: "invalid", expected: model.CarrierUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			assert.Equal(t, tt.expected, model.DetectCarrier(tt.number))
+		})
+	}
+
+	// Longer prefixes take precedence over the built-in table
+	require.NoError(t, model.RegisterCarrierPrefix("961", model.CarrierMobifone))
+	defer model.RegisterCarrierPrefix("961", model.CarrierUnknown)
+	assert.Equal(t, model.CarrierMobifone, model.DetectCarrier("0961234567"))
+	assert.Equal(t, model.CarrierViettel, model.DetectCarrier("0971234567"))
+
+	assert.Error(t, model.RegisterCarrierPrefix("09x", model.CarrierViettel))
+}
+
+// TestRequestValidationNormalizesRecipient tests that Validate rewrites the recipient to E.164
+func TestRequestValidationNormalizesRecipient(t *testing.T) {
+	req := model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "0912 345 678"},
+		Region:  "VN",
+	}
+	require.NoError(t, req.Validate())
+	assert.Equal(t, "+84912345678", req.Message.To)
+
+	// Without a region, national numbers cannot be read
+	req.Message.To = "0912345678"
+	req.Region = ""
+	err := req.Validate()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, model.ErrInvalidPhoneNumber))
+}
+
 // TestRequestValidation tests validating request structures
 func TestRequestValidation(t *testing.T) {
 	tests := []struct {
@@ -274,7 +563,7 @@ func TestRequestValidation(t *testing.T) {
 			request: model.SendSMSRequest{
 				Message: model.Message{
 					From: "Sender",
-					To:   "+1234567890",
+					To:   "+14155552671",
 					By:   "TestApp",
 				},
 			},
@@ -296,7 +585,7 @@ func TestRequestValidation(t *testing.T) {
 			request: model.SendSMSRequest{
 				Message: model.Message{
 					From: "",
-					To:   "+1234567890",
+					To:   "+14155552671",
 					By:   "TestApp",
 				},
 			},
@@ -307,7 +596,7 @@ func TestRequestValidation(t *testing.T) {
 			request: model.SendVoiceRequest{
 				Message: model.Message{
 					From: "Sender",
-					To:   "+1234567890",
+					To:   "+14155552671",
 					By:   "TestApp",
 				},
 			},
@@ -329,7 +618,7 @@ func TestRequestValidation(t *testing.T) {
 			request: model.SendVoiceRequest{
 				Message: model.Message{
 					From: "",
-					To:   "+1234567890",
+					To:   "+14155552671",
 					By:   "TestApp",
 				},
 			},
@@ -390,3 +679,29 @@ func TestValidationErrorString(t *testing.T) {
 	assert.Contains(t, err.Error(), "to")
 	assert.Contains(t, err.Error(), "invalid phone number")
 }
+
+// TestInboundMessageKeyword tests extracting the keyword of an inbound message
+func TestInboundMessageKeyword(t *testing.T) {
+	testCases := map[string]string{
+		"STOP":               "STOP",
+		"stop":               "STOP",
+		"  Stop.":            "STOP",
+		"yes please":         "YES",
+		"\"HELP\"":           "HELP",
+		"Hủy dịch vụ":        "HỦY",
+		"":                   "",
+		"   ":                "",
+		"!!!":                "",
+		"STOP\nsent from my": "STOP",
+	}
+
+	for body, expected := range testCases {
+		msg := model.InboundMessage{Body: body}
+		assert.Equal(t, expected, msg.Keyword(), "body %q", body)
+	}
+
+	msg := model.InboundMessage{Provider: "twilio", From: "+84912345678", To: "8079", Body: "STOP"}
+	assert.Contains(t, msg.String(), "twilio")
+	assert.Contains(t, msg.String(), "+84912345678")
+	assert.Contains(t, msg.String(), "STOP")
+}
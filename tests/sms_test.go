@@ -3,12 +3,21 @@ package tests
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-fork/sms"
+	"github.com/go-fork/sms/breaker"
+	"github.com/go-fork/sms/client"
+	"github.com/go-fork/sms/config"
 	"github.com/go-fork/sms/model"
+	"github.com/go-fork/sms/ratelimit"
+	"github.com/go-fork/sms/suppression"
+	"github.com/go-fork/sms/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -34,6 +43,16 @@ func (m *MockProvider) SendVoiceCall(ctx context.Context, req model.SendVoiceReq
 	return args.Get(0).(model.SendVoiceResponse), args.Error(1)
 }
 
+// MockStatusProvider implements model.StatusProvider for testing
+type MockStatusProvider struct {
+	MockProvider
+}
+
+func (m *MockStatusProvider) GetMessageStatus(ctx context.Context, messageID string) (model.MessageStatusResponse, error) {
+	args := m.Called(ctx, messageID)
+	return args.Get(0).(model.MessageStatusResponse), args.Error(1)
+}
+
 // createTempConfig creates a temporary config file for testing
 func createTempConfig(content string) (string, error) {
 	tmpfile, err := os.CreateTemp("", "sms-config-*.yaml")
@@ -197,6 +216,184 @@ providers:
 	assert.Error(t, err)
 }
 
+// TestNewModuleWithConfig tests module creation from a parsed configuration
+func TestNewModuleWithConfig(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "provider1",
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		Providers:       map[string]interface{}{"provider1": map[string]interface{}{}},
+	}
+	module, err := sms.NewModuleWithConfig(cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, module)
+
+	// The configuration is validated
+	cfg.SMSTemplate = ""
+	module, err = sms.NewModuleWithConfig(cfg)
+	assert.ErrorIs(t, err, config.ErrMissingSMSTemplate)
+	assert.Nil(t, module)
+
+	_, err = sms.NewModuleWithConfig(nil)
+	assert.Error(t, err)
+}
+
+// TestLoadProviders tests building every configured provider from one configuration
+func TestLoadProviders(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "provider2",
+		HTTPTimeout:     10 * time.Second,
+		SMSTemplate:     "{message}",
+		VoiceTemplate:   "{message}",
+		Providers: map[string]interface{}{
+			"provider1": map[string]interface{}{"api_key": "key1"},
+			"provider2": map[string]interface{}{"api_key": "key2"},
+		},
+	}
+
+	var clients []*client.Client
+	constructor := func(name string) sms.ProviderConstructor {
+		return func(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
+			settings, err := cfg.GetProviderConfig(name)
+			if err != nil {
+				return nil, err
+			}
+			if settings["api_key"] == "" {
+				return nil, errors.New("api_key is required")
+			}
+			clients = append(clients, httpClient)
+
+			provider := new(MockProvider)
+			provider.On("Name").Return(name)
+			return provider, nil
+		}
+	}
+
+	t.Run("Registers every configured provider", func(t *testing.T) {
+		clients = nil
+		module, err := sms.NewModuleWithConfig(cfg)
+		require.NoError(t, err)
+
+		err = module.LoadProviders(map[string]sms.ProviderConstructor{
+			"provider1": constructor("provider1"),
+			"provider2": constructor("provider2"),
+			"provider3": constructor("provider3"),
+		})
+		require.NoError(t, err)
+
+		for _, name := range []string{"provider1", "provider2"} {
+			_, err := module.GetProvider(name)
+			assert.NoError(t, err)
+		}
+		_, err = module.GetProvider("provider3")
+		assert.Error(t, err, "providers missing from the configuration are not built")
+
+		active, err := module.GetActiveProvider()
+		require.NoError(t, err)
+		assert.Equal(t, "provider2", active.Name())
+
+		// The providers share one HTTP client
+		require.Len(t, clients, 2)
+		assert.Same(t, clients[0], clients[1])
+
+		// Loading again fails as the providers are already registered
+		assert.Error(t, module.LoadProviders(map[string]sms.ProviderConstructor{
+			"provider1": constructor("provider1"),
+			"provider2": constructor("provider2"),
+		}))
+	})
+
+	t.Run("Missing constructor", func(t *testing.T) {
+		module, err := sms.NewModuleWithConfig(cfg)
+		require.NoError(t, err)
+
+		err = module.LoadProviders(map[string]sms.ProviderConstructor{"provider1": constructor("provider1")})
+		assert.ErrorIs(t, err, sms.ErrNoProviderFactory)
+		assert.ErrorContains(t, err, "no factory registered for provider 'provider2'")
+
+		// Nothing is registered when a provider cannot be built
+		_, err = module.GetProvider("provider1")
+		assert.Error(t, err)
+	})
+
+	t.Run("Constructor error", func(t *testing.T) {
+		module, err := sms.NewModuleWithConfig(cfg)
+		require.NoError(t, err)
+
+		failing := func(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
+			return nil, errors.New("invalid credentials")
+		}
+		err = module.LoadProviders(map[string]sms.ProviderConstructor{
+			"provider1": constructor("provider1"),
+			"provider2": failing,
+		})
+		assert.ErrorContains(t, err, "failed to create provider 'provider2': invalid credentials")
+	})
+}
+
+// TestNewModuleFromConfig tests building providers from the registered factories
+func TestNewModuleFromConfig(t *testing.T) {
+	factory := func(cfg config.ConfigProvider, httpClient *client.Client) (model.Provider, error) {
+		settings, err := cfg.GetProviderConfig("factory_sms")
+		if err != nil {
+			return nil, err
+		}
+		if settings["api_key"] == nil {
+			return nil, errors.New("api_key is required")
+		}
+
+		provider := new(MockProvider)
+		provider.On("Name").Return("factory_sms")
+		return provider, nil
+	}
+	require.NoError(t, sms.RegisterProviderFactory("factory_sms", factory))
+	assert.Contains(t, sms.ProviderFactories(), "factory_sms")
+
+	assert.Error(t, sms.RegisterProviderFactory("", factory))
+	assert.Error(t, sms.RegisterProviderFactory("factory_nil", nil))
+	assert.Panics(t, func() { sms.MustRegisterProviderFactory("factory_nil", nil) })
+
+	newConfig := func(providers map[string]interface{}) *config.Config {
+		return &config.Config{
+			DefaultProvider: "factory_sms",
+			HTTPTimeout:     10 * time.Second,
+			SMSTemplate:     "{message}",
+			VoiceTemplate:   "{message}",
+			Providers:       providers,
+		}
+	}
+
+	t.Run("Registers configured providers", func(t *testing.T) {
+		module, err := sms.NewModuleFromConfig(newConfig(map[string]interface{}{
+			"factory_sms": map[string]interface{}{"api_key": "key"},
+		}))
+		require.NoError(t, err)
+
+		active, err := module.GetActiveProvider()
+		require.NoError(t, err)
+		assert.Equal(t, "factory_sms", active.Name())
+	})
+
+	t.Run("Aggregates errors", func(t *testing.T) {
+		module, err := sms.NewModuleFromConfig(newConfig(map[string]interface{}{
+			"factory_sms": map[string]interface{}{},
+			"plivo":       map[string]interface{}{"auth_id": "id"},
+			"vonage":      map[string]interface{}{"api_key": "key"},
+		}))
+		assert.Nil(t, module)
+		assert.ErrorIs(t, err, sms.ErrNoProviderFactory)
+		assert.ErrorContains(t, err, "failed to create provider 'factory_sms': api_key is required")
+		assert.ErrorContains(t, err, "no factory registered for provider 'plivo'")
+		assert.ErrorContains(t, err, "no factory registered for provider 'vonage'")
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		_, err := sms.NewModuleFromConfig(newConfig(nil))
+		assert.ErrorIs(t, err, config.ErrNoProvidersConfigured)
+	})
+}
+
 // TestSendSMS tests sending SMS messages
 func TestSendSMS(t *testing.T) {
 	configFile, err := createTempConfig(`
@@ -223,7 +420,7 @@ providers:
 	successReq := model.SendSMSRequest{
 		Message: model.Message{
 			From: "Sender",
-			To:   "+1234567890",
+			To:   "+14155552671",
 			By:   "TestApp",
 		},
 		Data: map[string]interface{}{
@@ -238,8 +435,14 @@ providers:
 		SentAt:    time.Now(),
 	}
 
-	provider.On("SendSMS", mock.Anything, successReq).Return(successResp, nil)
+	// The provider receives the request with the default template resolved and rendered
+	sentReq := successReq
+	sentReq.Template = "Your message is {message}"
+	sentReq.Body = "Your message is Test message"
 
+	provider.On("SendSMS", mock.Anything, sentReq).Return(successResp, nil)
+
+	// Invalid requests are rejected before reaching the provider
 	errorReq := model.SendSMSRequest{
 		Message: model.Message{
 			From: "Sender",
@@ -248,8 +451,6 @@ providers:
 		},
 	}
 
-	provider.On("SendSMS", mock.Anything, errorReq).Return(model.SendSMSResponse{}, errors.New("invalid phone number"))
-
 	// Add the mock provider
 	err = module.AddProvider(provider)
 	require.NoError(t, err)
@@ -260,11 +461,14 @@ providers:
 	assert.Equal(t, "msg_123", resp.MessageID)
 	assert.Equal(t, model.StatusSent, resp.Status)
 	assert.Equal(t, "test_provider", resp.Provider)
+	assert.Equal(t, "Your message is Test message", resp.Body)
+	assert.Equal(t, model.EncodingGSM7, resp.Encoding)
+	assert.Equal(t, 1, resp.Segments)
 
 	// Test error case
 	_, err = module.SendSMS(context.Background(), errorReq)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid phone number")
+	assert.Contains(t, err.Error(), "invalid recipient phone number")
 
 	// Test with no active provider
 	newModule, err := sms.NewModule(configFile)
@@ -303,7 +507,7 @@ providers:
 	successReq := model.SendVoiceRequest{
 		Message: model.Message{
 			From: "Sender",
-			To:   "+1234567890",
+			To:   "+14155552671",
 			By:   "TestApp",
 		},
 		Data: map[string]interface{}{
@@ -319,8 +523,14 @@ providers:
 		Duration:  0,
 	}
 
-	provider.On("SendVoiceCall", mock.Anything, successReq).Return(successResp, nil)
+	// The provider receives the request with the default template resolved and rendered
+	sentReq := successReq
+	sentReq.Template = "Your message is {message}"
+	sentReq.Body = "Your message is Test message"
 
+	provider.On("SendVoiceCall", mock.Anything, sentReq).Return(successResp, nil)
+
+	// Invalid requests are rejected before reaching the provider
 	errorReq := model.SendVoiceRequest{
 		Message: model.Message{
 			From: "Sender",
@@ -329,8 +539,6 @@ providers:
 		},
 	}
 
-	provider.On("SendVoiceCall", mock.Anything, errorReq).Return(model.SendVoiceResponse{}, errors.New("invalid phone number"))
-
 	// Add the mock provider
 	err = module.AddProvider(provider)
 	require.NoError(t, err)
@@ -345,7 +553,7 @@ providers:
 	// Test error case
 	_, err = module.SendVoiceCall(context.Background(), errorReq)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid phone number")
+	assert.Contains(t, err.Error(), "invalid recipient phone number")
 
 	// Test with no active provider
 	newModule, err := sms.NewModule(configFile)
@@ -357,3 +565,1298 @@ providers:
 	// Verify all expectations were met
 	provider.AssertExpectations(t)
 }
+
+// TestSendSMSFailover tests falling back to the configured failover providers
+func TestSendSMSFailover(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+failover: [primary, secondary, tertiary]
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+  tertiary:
+    api_key: key3
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	req := model.SendSMSRequest{
+		Message: model.Message{
+			From: "Sender",
+			To:   "+14155552671",
+			By:   "TestApp",
+		},
+		Data: map[string]interface{}{
+			"message": "Test message",
+		},
+	}
+
+	t.Run("Falls back to next provider", func(t *testing.T) {
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		primary := new(MockProvider)
+		primary.On("Name").Return("primary")
+		primary.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{}, errors.New("primary unavailable"))
+
+		secondary := new(MockProvider)
+		secondary.On("Name").Return("secondary")
+		secondary.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_456", Status: model.StatusSent}, nil)
+
+		tertiary := new(MockProvider)
+		tertiary.On("Name").Return("tertiary")
+
+		require.NoError(t, module.AddProvider(primary))
+		require.NoError(t, module.AddProvider(secondary))
+		require.NoError(t, module.AddProvider(tertiary))
+
+		resp, err := module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "msg_456", resp.MessageID)
+		assert.Equal(t, "secondary", resp.Provider)
+		require.Len(t, resp.Attempts, 2)
+		assert.Equal(t, "primary", resp.Attempts[0].Provider)
+		assert.Contains(t, resp.Attempts[0].Error, "primary unavailable")
+		assert.Equal(t, "secondary", resp.Attempts[1].Provider)
+		assert.Empty(t, resp.Attempts[1].Error)
+
+		tertiary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+	})
+
+	t.Run("All providers fail", func(t *testing.T) {
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		for _, name := range []string{"primary", "secondary", "tertiary"} {
+			provider := new(MockProvider)
+			provider.On("Name").Return(name)
+			provider.On("SendSMS", mock.Anything, mock.Anything).
+				Return(model.SendSMSResponse{}, errors.New(name+" unavailable"))
+			require.NoError(t, module.AddProvider(provider))
+		}
+
+		_, err = module.SendSMS(context.Background(), req)
+		require.Error(t, err)
+
+		var failoverErr *sms.FailoverError
+		require.True(t, errors.As(err, &failoverErr))
+		require.Len(t, failoverErr.Attempts, 3)
+		assert.Equal(t, "tertiary", failoverErr.Attempts[2].Provider)
+		assert.Contains(t, err.Error(), "after 3 attempts across 3 providers")
+		assert.Contains(t, err.Error(), "secondary unavailable")
+	})
+
+	t.Run("Stops on request errors", func(t *testing.T) {
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		providers := make([]*MockProvider, 0, 3)
+		for _, name := range []string{"primary", "secondary", "tertiary"} {
+			provider := new(MockProvider)
+			provider.On("Name").Return(name)
+			require.NoError(t, module.AddProvider(provider))
+			providers = append(providers, provider)
+		}
+
+		// A missing template fails the same way for every provider, so only one is tried
+		missing := req
+		missing.TemplateName = "unknown"
+		_, err = module.SendSMS(context.Background(), missing)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, config.ErrTemplateNotFound)
+
+		var failoverErr *sms.FailoverError
+		assert.False(t, errors.As(err, &failoverErr))
+		assert.NotContains(t, err.Error(), "attempts")
+		for _, provider := range providers {
+			provider.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Uses provider error categories", func(t *testing.T) {
+		configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 3
+retry_delay: 1ms
+failover: [primary, secondary]
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+`)
+		require.NoError(t, err)
+		defer os.Remove(configFile)
+
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		primary := new(MockProvider)
+		primary.On("Name").Return("primary")
+		primary.On("SendSMS", mock.Anything, mock.Anything).Return(model.SendSMSResponse{}, &model.ProviderError{
+			Provider: "primary", Code: "99", Category: model.ErrorCategoryTransient, Message: "unavailable",
+		}).Once()
+		primary.On("SendSMS", mock.Anything, mock.Anything).Return(model.SendSMSResponse{}, &model.ProviderError{
+			Provider: "primary", Code: "21211", HTTPStatus: 400, Category: model.ErrorCategoryInvalidRecipient,
+		})
+
+		secondary := new(MockProvider)
+		secondary.On("Name").Return("secondary")
+
+		require.NoError(t, module.AddProvider(primary))
+		require.NoError(t, module.AddProvider(secondary))
+
+		// The transient error is retried; the invalid recipient is neither retried nor failed over
+		_, err = module.SendSMS(context.Background(), req)
+		require.Error(t, err)
+		assert.Equal(t, model.ErrorCategoryInvalidRecipient, model.ErrorCategoryOf(err))
+		assert.Contains(t, err.Error(), "failed to send SMS after 2 attempts:")
+		primary.AssertNumberOfCalls(t, "SendSMS", 2)
+		secondary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+	})
+}
+
+// TestSendWithProviderOverride tests sending through a provider named on the request
+func TestSendWithProviderOverride(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 2
+retry_delay: 10ms
+failover: [primary, secondary]
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	primary := new(MockProvider)
+	primary.On("Name").Return("primary")
+
+	secondary := new(MockProvider)
+	secondary.On("Name").Return("secondary")
+	secondary.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{}, errors.New("temporary failure")).Once()
+	secondary.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{MessageID: "msg_override", Status: model.StatusSent}, nil).Once()
+	secondary.On("SendVoiceCall", mock.Anything, mock.Anything).
+		Return(model.SendVoiceResponse{CallID: "call_override", Status: model.CallStatusQueued}, nil)
+
+	require.NoError(t, module.AddProvider(primary))
+	require.NoError(t, module.AddProvider(secondary))
+
+	req := model.SendSMSRequest{
+		Message:  model.Message{From: "Sender", To: "+14155552671"},
+		Provider: "secondary",
+		Data:     map[string]interface{}{"message": "Test message"},
+	}
+
+	// The named provider is retried and the response is normalized
+	resp, err := module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "msg_override", resp.MessageID)
+	assert.Equal(t, "secondary", resp.Provider)
+	assert.Equal(t, "Your message is Test message", resp.Body)
+	require.Len(t, resp.Attempts, 1)
+	secondary.AssertNumberOfCalls(t, "SendSMS", 2)
+	primary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+
+	// The active provider is unchanged
+	active, err := module.GetActiveProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "primary", active.Name())
+
+	voiceResp, err := module.SendVoiceCall(context.Background(), model.SendVoiceRequest{
+		Message:  model.Message{From: "Sender", To: "+14155552671"},
+		Provider: "secondary",
+		Data:     map[string]interface{}{"message": "Test call"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "call_override", voiceResp.CallID)
+	assert.Equal(t, "secondary", voiceResp.Provider)
+
+	// A named provider that fails does not fail over to the others
+	secondary.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{}, errors.New("invalid recipient"))
+	_, err = module.SendSMS(context.Background(), req)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "all providers failed")
+	primary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+
+	// Unknown providers are rejected
+	req.Provider = "unknown"
+	_, err = module.SendSMS(context.Background(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "provider 'unknown' not found")
+}
+
+// TestRemoveAndReplaceProvider tests taking providers out of rotation at runtime
+func TestRemoveAndReplaceProvider(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: provider1
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+failover: [provider2]
+
+providers:
+  provider1:
+    api_key: key1
+  provider2:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	provider1 := new(MockProvider)
+	provider1.On("Name").Return("provider1")
+
+	provider2 := new(MockProvider)
+	provider2.On("Name").Return("provider2")
+
+	require.NoError(t, module.AddProvider(provider1))
+	require.NoError(t, module.AddProvider(provider2))
+
+	// Replacing the active provider keeps it active
+	replacement := new(MockProvider)
+	replacement.On("Name").Return("provider1")
+	require.NoError(t, module.ReplaceProvider(replacement))
+
+	active, err := module.GetActiveProvider()
+	require.NoError(t, err)
+	assert.Same(t, replacement, active)
+
+	// Replacing an unknown provider fails
+	unknown := new(MockProvider)
+	unknown.On("Name").Return("provider3")
+	assert.Error(t, module.ReplaceProvider(unknown))
+
+	// Removing the active provider falls back to the failover provider
+	require.NoError(t, module.RemoveProvider("provider1"))
+	active, err = module.GetActiveProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "provider2", active.Name())
+
+	_, err = module.GetProvider("provider1")
+	assert.Error(t, err)
+	assert.Error(t, module.RemoveProvider("provider1"))
+
+	// Removing the last provider leaves no active provider
+	require.NoError(t, module.RemoveProvider("provider2"))
+	_, err = module.GetActiveProvider()
+	assert.Error(t, err)
+}
+
+// TestConcurrentSendAndSwitch tests sending while providers are switched and replaced
+func TestConcurrentSendAndSwitch(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: provider1
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+
+providers:
+  provider1:
+    api_key: key1
+  provider2:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	newProvider := func(name string) *MockProvider {
+		provider := new(MockProvider)
+		provider.On("Name").Return(name)
+		provider.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_" + name, Status: model.StatusSent}, nil)
+		return provider
+	}
+
+	require.NoError(t, module.AddProvider(newProvider("provider1")))
+	require.NoError(t, module.AddProvider(newProvider("provider2")))
+
+	req := model.SendSMSRequest{
+		Message: model.Message{
+			From: "Sender",
+			To:   "+14155552671",
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			_, err := module.SendSMS(context.Background(), req)
+			assert.NoError(t, err)
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("provider%d", i%2+1)
+			assert.NoError(t, module.SwitchProvider(name))
+			assert.NoError(t, module.ReplaceProvider(newProvider(name)))
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestTemplateResolution tests choosing between request, provider and global templates
+func TestTemplateResolution(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: provider1
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+sms_template: "Global: {message}"
+voice_template: "Global voice: {message}"
+
+providers:
+  provider1:
+    api_key: key1
+    sms_template: "Provider: {message}"
+  provider2:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	provider1 := new(MockProvider)
+	provider1.On("Name").Return("provider1")
+	provider1.On("SendSMS", mock.Anything, mock.MatchedBy(func(req model.SendSMSRequest) bool {
+		return req.Body == "Provider: hello"
+	})).Return(model.SendSMSResponse{MessageID: "msg_1", Status: model.StatusSent}, nil)
+
+	provider2 := new(MockProvider)
+	provider2.On("Name").Return("provider2")
+
+	require.NoError(t, module.AddProvider(provider1))
+	require.NoError(t, module.AddProvider(provider2))
+
+	newRequest := func(template string) model.SendSMSRequest {
+		return model.SendSMSRequest{
+			Message:  model.Message{From: "Sender", To: "+14155552671"},
+			Template: template,
+			Data:     map[string]interface{}{"message": "hello"},
+		}
+	}
+
+	// The provider template is used when the request has none
+	resp, err := module.SendSMS(context.Background(), newRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "Provider: hello", resp.Body)
+
+	// The request template takes precedence
+	body, err := module.RenderSMS(newRequest("Request: {message}"))
+	require.NoError(t, err)
+	assert.Equal(t, "Request: hello", body)
+
+	// The global default applies to providers without their own template
+	require.NoError(t, module.SwitchProvider("provider2"))
+	body, err = module.RenderSMS(newRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, "Global: hello", body)
+
+	body, err = module.RenderVoice(model.SendVoiceRequest{
+		Message: model.Message{From: "Sender", To: "+14155552671"},
+		Data:    map[string]interface{}{"message": "hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Global voice: hello", body)
+}
+
+// TestSendSMSWithNamedTemplate tests sending with a template from the registry
+func TestSendSMSWithNamedTemplate(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: test_provider
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+default_locale: en
+templates:
+  otp:
+    vi: "Ma OTP cua ban la {otp}"
+    en: "Your OTP is {otp}"
+
+providers:
+  test_provider:
+    api_key: test_key
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	provider := new(MockProvider)
+	provider.On("Name").Return("test_provider")
+	provider.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{MessageID: "msg_1", Status: model.StatusSent}, nil)
+	require.NoError(t, module.AddProvider(provider))
+
+	assert.Equal(t, map[string][]string{"otp": {"en", "vi"}}, module.ListTemplates())
+
+	req := model.SendSMSRequest{
+		Message:      model.Message{From: "Sender", To: "+84912345678"},
+		TemplateName: "otp",
+		Locale:       "vi-VN",
+		Data:         map[string]interface{}{"otp": "123456"},
+	}
+
+	resp, err := module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Ma OTP cua ban la 123456", resp.Body)
+
+	// Unknown template names are reported instead of silently using the default template
+	req.TemplateName = "unknown"
+	_, err = module.SendSMS(context.Background(), req)
+	assert.ErrorIs(t, err, config.ErrTemplateNotFound)
+}
+
+// TestSendSMSStrictTemplates tests rejecting messages with unresolved placeholders
+func TestSendSMSStrictTemplates(t *testing.T) {
+	newModule := func(strict bool) *sms.Module {
+		configFile, err := createTempConfig(fmt.Sprintf(`
+default_provider: test_provider
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+strict_templates: %t
+
+providers:
+  test_provider:
+    api_key: test_key
+`, strict))
+		require.NoError(t, err)
+		defer os.Remove(configFile)
+
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		provider := new(MockProvider)
+		provider.On("Name").Return("test_provider")
+		provider.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_1", Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(provider))
+
+		return module
+	}
+
+	req := model.SendSMSRequest{
+		Message:  model.Message{From: "Sender", To: "+14155552671"},
+		Template: "Your OTP is {otp}",
+	}
+
+	// Lenient by default
+	resp, err := newModule(false).SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Your OTP is {otp}", resp.Body)
+
+	// Strict per request
+	strictReq := req
+	strictReq.Strict = true
+	_, err = newModule(false).SendSMS(context.Background(), strictReq)
+	var missingErr *model.MissingVariablesError
+	require.True(t, errors.As(err, &missingErr))
+	assert.Equal(t, []string{"otp"}, missingErr.Variables)
+
+	// Strict globally
+	_, err = newModule(true).SendSMS(context.Background(), req)
+	assert.True(t, errors.As(err, &missingErr))
+}
+
+// TestSendSMSTransliteration tests the global, provider and request transliteration settings
+func TestSendSMSTransliteration(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: esms
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+transliterate: false
+
+providers:
+  esms:
+    api_key: key1
+    transliterate: true
+  twilio:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	for _, name := range []string{"esms", "twilio"} {
+		provider := new(MockProvider)
+		provider.On("Name").Return(name)
+		provider.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_" + name, Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(provider))
+	}
+
+	req := model.SendSMSRequest{
+		Message:  model.Message{From: "Sender", To: "+84912345678"},
+		Template: "Mã OTP của bạn là {otp}",
+		Data:     map[string]interface{}{"otp": "123456"},
+	}
+
+	// Enabled for the eSMS provider
+	resp, err := module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Ma OTP cua ban la 123456", resp.Body)
+	assert.Equal(t, model.EncodingGSM7, resp.Encoding)
+	require.NotNil(t, resp.Transliteration)
+	assert.True(t, resp.Transliteration.Changed())
+
+	// Disabled for the request
+	disabled := false
+	req.Transliterate = &disabled
+	resp, err = module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Mã OTP của bạn là 123456", resp.Body)
+	assert.Equal(t, model.EncodingUCS2, resp.Encoding)
+	assert.Nil(t, resp.Transliteration)
+
+	// Disabled globally for providers without their own setting
+	req.Transliterate = nil
+	require.NoError(t, module.SwitchProvider("twilio"))
+	resp, err = module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Mã OTP của bạn là 123456", resp.Body)
+}
+
+// TestSendSMSRouting tests that routing rules pick the provider and sender
+func TestSendSMSRouting(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: twilio
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+failover: [twilio]
+routes:
+  - name: vietnam
+    prefixes: ["+84"]
+    provider: esms
+    sender: MYBRAND
+
+providers:
+  twilio:
+    api_key: key1
+  esms:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	senders := make(map[string]string)
+	for _, name := range []string{"twilio", "esms"} {
+		name := name
+		provider := new(MockProvider)
+		provider.On("Name").Return(name)
+		provider.On("SendSMS", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				senders[name] = args.Get(1).(model.SendSMSRequest).Message.From
+			}).
+			Return(model.SendSMSResponse{MessageID: "msg_" + name, Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(provider))
+	}
+
+	vietnamese := model.SendSMSRequest{
+		Message: model.Message{To: "+84912345678"},
+		Data:    map[string]interface{}{"message": "Xin chao"},
+	}
+
+	// The route can be inspected without sending
+	route, err := module.ResolveRoute(vietnamese)
+	require.NoError(t, err)
+	assert.Equal(t, sms.Route{Rule: "vietnam", Provider: "esms", Failover: []string{"twilio"}, Sender: "MYBRAND", Carrier: model.CarrierVinaphone}, route)
+
+	resp, err := module.SendSMS(context.Background(), vietnamese)
+	require.NoError(t, err)
+	assert.Equal(t, "esms", resp.Provider)
+	assert.Equal(t, "vietnam", resp.Route)
+	assert.Equal(t, "MYBRAND", senders["esms"])
+
+	// A sender on the request is kept
+	vietnamese.Message.From = "OTHER"
+	_, err = module.SendSMS(context.Background(), vietnamese)
+	require.NoError(t, err)
+	assert.Equal(t, "OTHER", senders["esms"])
+
+	// Messages without a matching rule use the active provider
+	resp, err = module.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "+14155550100"},
+		Data:    map[string]interface{}{"message": "Hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "twilio", resp.Provider)
+	assert.Empty(t, resp.Route)
+
+	// A provider named on the request takes precedence over the rules
+	vietnamese.Provider = "twilio"
+	route, err = module.ResolveRoute(vietnamese)
+	require.NoError(t, err)
+	assert.Equal(t, sms.Route{Provider: "twilio", Sender: "OTHER", Carrier: model.CarrierVinaphone}, route)
+}
+
+// TestSendSMSNormalizesRecipient tests that national numbers are normalized with the default region
+func TestSendSMSNormalizesRecipient(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: twilio
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+default_region: VN
+routes:
+  - name: vietnam
+    country_codes: [84]
+    provider: esms
+
+providers:
+  twilio:
+    api_key: key1
+  esms:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	twilioProvider := new(MockProvider)
+	twilioProvider.On("Name").Return("twilio")
+	require.NoError(t, module.AddProvider(twilioProvider))
+
+	esmsProvider := new(MockProvider)
+	esmsProvider.On("Name").Return("esms")
+	esmsProvider.On("SendSMS", mock.Anything, mock.MatchedBy(func(req model.SendSMSRequest) bool {
+		return req.Message.To == "+84912345678"
+	})).Return(model.SendSMSResponse{MessageID: "msg_123", Status: model.StatusSent}, nil)
+	require.NoError(t, module.AddProvider(esmsProvider))
+
+	resp, err := module.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "0912345678"},
+		Data:    map[string]interface{}{"message": "Xin chao"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "esms", resp.Provider)
+	assert.Equal(t, "vietnam", resp.Route)
+	esmsProvider.AssertExpectations(t)
+}
+
+// TestSendSMSCarrierRouting tests routing Vietnamese numbers by carrier
+func TestSendSMSCarrierRouting(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: esms
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+default_region: VN
+routes:
+  - name: viettel
+    carriers: [Viettel]
+    provider: speedsms
+
+providers:
+  esms:
+    api_key: key1
+  speedsms:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	for _, name := range []string{"esms", "speedsms"} {
+		provider := new(MockProvider)
+		provider.On("Name").Return(name)
+		provider.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_" + name, Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(provider))
+	}
+
+	tests := []struct {
+		to       string
+		provider string
+		carrier  model.Carrier
+	}{
+		{to: "0961234567", provider: "speedsms", carrier: model.CarrierViettel},
+		{to: "0912345678", provider: "esms", carrier: model.CarrierVinaphone},
+	}
+
+	for _, tt := range tests {
+		resp, err := module.SendSMS(context.Background(), model.SendSMSRequest{
+			Message: model.Message{From: "Sender", To: tt.to},
+			Data:    map[string]interface{}{"message": "Xin chao"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tt.provider, resp.Provider)
+		assert.Equal(t, tt.carrier, resp.Carrier)
+	}
+}
+
+// TestSendSMSRetryHook tests the retry hook and the configured backoff settings
+func TestSendSMSRetryHook(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 3
+retry_delay: 5ms
+retry_max_delay: 8ms
+retry_multiplier: 3
+
+providers:
+  primary:
+    api_key: key1
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	transient := &model.ProviderError{Provider: "primary", Category: model.ErrorCategoryTransient}
+	provider := new(MockProvider)
+	provider.On("Name").Return("primary")
+	provider.On("SendSMS", mock.Anything, mock.Anything).Return(model.SendSMSResponse{}, transient).Twice()
+	provider.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{MessageID: "msg_123", Status: model.StatusSent}, nil).Once()
+	require.NoError(t, module.AddProvider(provider))
+
+	type retryEvent struct {
+		provider  string
+		attempt   int
+		nextDelay time.Duration
+	}
+	var events []retryEvent
+	module.SetRetryHook(func(providerName string, attempt int, err error, nextDelay time.Duration) {
+		assert.ErrorIs(t, err, transient)
+		events = append(events, retryEvent{providerName, attempt, nextDelay})
+	})
+
+	resp, err := module.SendSMS(context.Background(), model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "+14155552671"},
+		Data:    map[string]interface{}{"message": "Test message"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "msg_123", resp.MessageID)
+
+	// The first retry waits retry_delay; the next is multiplied and capped at retry_max_delay
+	assert.Equal(t, []retryEvent{
+		{"primary", 1, 5 * time.Millisecond},
+		{"primary", 2, 8 * time.Millisecond},
+	}, events)
+}
+
+// TestSendSMSCircuitBreaker tests that a failing provider is taken out of rotation
+func TestSendSMSCircuitBreaker(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+failover: [primary, secondary]
+circuit_breaker:
+  enabled: true
+  failure_threshold: 1
+  min_requests: 2
+  cool_down: 1h
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	primary := new(MockProvider)
+	primary.On("Name").Return("primary")
+	primary.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{}, &model.ProviderError{Provider: "primary", Category: model.ErrorCategoryTransient}).
+		Times(2)
+	require.NoError(t, module.AddProvider(primary))
+
+	secondary := new(MockProvider)
+	secondary.On("Name").Return("secondary")
+	secondary.On("SendSMS", mock.Anything, mock.Anything).
+		Return(model.SendSMSResponse{MessageID: "msg_456", Status: model.StatusSent}, nil)
+	require.NoError(t, module.AddProvider(secondary))
+
+	var changes []breaker.State
+	module.SetBreakerHook(func(provider string, from, to breaker.State) {
+		assert.Equal(t, "primary", provider)
+		changes = append(changes, to)
+	})
+
+	req := model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "+14155552671"},
+		Data:    map[string]interface{}{"message": "Test message"},
+	}
+
+	// Two failures open the primary circuit
+	for i := 0; i < 2; i++ {
+		resp, err := module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", resp.Provider)
+	}
+	assert.Equal(t, []breaker.State{breaker.StateOpen}, changes)
+
+	state, err := module.GetBreakerState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, breaker.StateOpen, state)
+
+	// The open provider is skipped without being called
+	resp, err := module.SendSMS(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", resp.Provider)
+	require.Len(t, resp.Attempts, 2)
+	assert.ErrorIs(t, resp.Attempts[0].Err, breaker.ErrOpen)
+	primary.AssertNumberOfCalls(t, "SendSMS", 2)
+
+	// Sending through the open provider alone fails fast
+	req.Provider = "primary"
+	_, err = module.SendSMS(context.Background(), req)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	primary.AssertNumberOfCalls(t, "SendSMS", 2)
+
+	require.NoError(t, module.ResetBreaker("primary"))
+	state, err = module.GetBreakerState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, breaker.StateClosed, state)
+	assert.Equal(t, []breaker.State{breaker.StateOpen, breaker.StateClosed}, changes)
+}
+
+// TestSendSMSRateLimits tests the per-provider and per-recipient rate limits
+func TestSendSMSRateLimits(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 10ms
+failover: [primary, secondary]
+rate_limits:
+  fail_fast: true
+  providers:
+    primary:
+      rate: 50
+      burst: 1
+  recipient:
+    limit: 3
+    period: 1h
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	newModule := func(t *testing.T) (*sms.Module, *MockProvider, *MockProvider) {
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+
+		primary := new(MockProvider)
+		primary.On("Name").Return("primary")
+		primary.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_123", Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(primary))
+
+		secondary := new(MockProvider)
+		secondary.On("Name").Return("secondary")
+		secondary.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_456", Status: model.StatusSent}, nil)
+		require.NoError(t, module.AddProvider(secondary))
+
+		return module, primary, secondary
+	}
+
+	request := func(to string) model.SendSMSRequest {
+		return model.SendSMSRequest{
+			Message: model.Message{From: "Sender", To: to},
+			Data:    map[string]interface{}{"message": "Test message"},
+		}
+	}
+
+	t.Run("Fails over when the provider has no token", func(t *testing.T) {
+		module, _, _ := newModule(t)
+
+		resp, err := module.SendSMS(context.Background(), request("+14155552671"))
+		require.NoError(t, err)
+		assert.Equal(t, "primary", resp.Provider)
+
+		resp, err = module.SendSMS(context.Background(), request("+14155552672"))
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", resp.Provider)
+		require.Len(t, resp.Attempts, 2)
+		assert.ErrorIs(t, resp.Attempts[0].Err, ratelimit.ErrRateLimited)
+	})
+
+	t.Run("Fails fast without failover", func(t *testing.T) {
+		module, _, _ := newModule(t)
+
+		req := request("+14155552671")
+		req.Provider = "primary"
+		_, err := module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+
+		_, err = module.SendSMS(context.Background(), req)
+		var limitErr *ratelimit.Error
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "primary", limitErr.Name)
+		assert.Greater(t, limitErr.RetryAfter, time.Duration(0))
+	})
+
+	t.Run("Removed and replaced providers start with a full bucket", func(t *testing.T) {
+		module, primary, _ := newModule(t)
+
+		req := request("+14155552671")
+		req.Provider = "primary"
+		_, err := module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+
+		require.NoError(t, module.ReplaceProvider(primary))
+		_, err = module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+
+		require.NoError(t, module.RemoveProvider("primary"))
+		require.NoError(t, module.AddProvider(primary))
+		_, err = module.SendSMS(context.Background(), req)
+		require.NoError(t, err)
+		primary.AssertNumberOfCalls(t, "SendSMS", 3)
+	})
+
+	t.Run("Waits for a token when requested", func(t *testing.T) {
+		module, primary, _ := newModule(t)
+
+		wait := false
+		req := request("+14155552671")
+		req.FailFast = &wait
+		for i := 0; i < 2; i++ {
+			resp, err := module.SendSMS(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, "primary", resp.Provider)
+		}
+		primary.AssertNumberOfCalls(t, "SendSMS", 2)
+	})
+
+	t.Run("Limits messages per recipient", func(t *testing.T) {
+		module, primary, secondary := newModule(t)
+
+		for i := 0; i < 3; i++ {
+			_, err := module.SendSMS(context.Background(), request("+84912345678"))
+			require.NoError(t, err)
+		}
+
+		// The national format is normalized before the recipient is counted
+		req := request("0912345678")
+		req.Region = "VN"
+		_, err := module.SendSMS(context.Background(), req)
+		var limitErr *ratelimit.Error
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "recipient +84912345678", limitErr.Name)
+
+		calls := len(primary.Calls) + len(secondary.Calls)
+		_, err = module.SendSMS(context.Background(), request("+84987654321"))
+		require.NoError(t, err)
+		assert.Greater(t, len(primary.Calls)+len(secondary.Calls), calls)
+	})
+}
+
+// TestGetMessageStatus tests looking up message status through the module
+func TestGetMessageStatus(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: provider1
+http_timeout: 10s
+retry_attempts: 0
+sms_template: "{message}"
+voice_template: "{message}"
+
+providers:
+  provider1:
+    api_key: key1
+  provider2:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	module, err := sms.NewModule(configFile)
+	require.NoError(t, err)
+
+	provider1 := new(MockStatusProvider)
+	provider1.On("Name").Return("provider1")
+	provider1.On("GetMessageStatus", mock.Anything, "msg-1").Return(model.MessageStatusResponse{
+		MessageID: "msg-1",
+		Status:    model.StatusDelivered,
+		RawStatus: "DELIVRD",
+	}, nil)
+	provider1.On("GetMessageStatus", mock.Anything, "msg-2").Return(model.MessageStatusResponse{}, errors.New("not found"))
+
+	provider2 := new(MockProvider)
+	provider2.On("Name").Return("provider2")
+
+	require.NoError(t, module.AddProvider(provider1))
+	require.NoError(t, module.AddProvider(provider2))
+
+	ctx := context.Background()
+	status, err := module.GetMessageStatus(ctx, "provider1", "msg-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusDelivered, status.Status)
+	assert.Equal(t, "DELIVRD", status.RawStatus)
+	assert.Equal(t, "provider1", status.Provider, "the provider name is filled in")
+
+	_, err = module.GetMessageStatus(ctx, "provider1", "msg-2")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = module.GetMessageStatus(ctx, "provider2", "msg-1")
+	assert.ErrorIs(t, err, sms.ErrStatusLookupNotSupported)
+
+	_, err = module.GetMessageStatus(ctx, "provider3", "msg-1")
+	assert.Error(t, err)
+
+	_, err = module.GetMessageStatus(ctx, "provider1", "")
+	assert.Error(t, err)
+}
+
+// TestSendSMSSuppression tests that suppressed recipients are not texted
+func TestSendSMSSuppression(t *testing.T) {
+	configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 1ms
+default_region: VN
+failover: [primary, secondary]
+suppression:
+  enabled: true
+
+providers:
+  primary:
+    api_key: key1
+  secondary:
+    api_key: key2
+`)
+	require.NoError(t, err)
+	defer os.Remove(configFile)
+
+	newModule := func(t *testing.T) (*sms.Module, *MockProvider, *MockProvider) {
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+		require.NotNil(t, module.GetSuppressionList())
+
+		primary := new(MockProvider)
+		primary.On("Name").Return("primary")
+		secondary := new(MockProvider)
+		secondary.On("Name").Return("secondary")
+		require.NoError(t, module.AddProvider(primary))
+		require.NoError(t, module.AddProvider(secondary))
+		return module, primary, secondary
+	}
+
+	req := model.SendSMSRequest{
+		Message: model.Message{From: "Sender", To: "0912345678"},
+		Body:    "Sale ends today",
+	}
+	ctx := context.Background()
+
+	t.Run("Blocks suppressed recipients", func(t *testing.T) {
+		module, primary, _ := newModule(t)
+		primary.On("SendSMS", mock.Anything, mock.Anything).
+			Return(model.SendSMSResponse{MessageID: "msg_123", Status: model.StatusSent}, nil)
+
+		// National and international forms of the number match
+		require.NoError(t, module.Suppress(ctx, suppression.Entry{Number: "+84 912 345 678"}))
+
+		_, err := module.SendSMS(ctx, req)
+		assert.ErrorIs(t, err, sms.ErrRecipientSuppressed)
+		var suppressedErr *sms.SuppressedError
+		require.True(t, errors.As(err, &suppressedErr))
+		assert.Equal(t, "+84912345678", suppressedErr.Recipient)
+		assert.Equal(t, suppression.ReasonManual, suppressedErr.Entry.Reason)
+		primary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+
+		// Categories configured to bypass the list are still sent
+		otp := req
+		otp.Category = model.CategoryOTP
+		_, err = module.SendSMS(ctx, otp)
+		assert.NoError(t, err)
+
+		marketing := req
+		marketing.Category = model.CategoryMarketing
+		_, err = module.SendSMS(ctx, marketing)
+		assert.ErrorIs(t, err, sms.ErrRecipientSuppressed)
+
+		require.NoError(t, module.Unsuppress(ctx, "84912345678"))
+		_, err = module.SendSMS(ctx, req)
+		assert.NoError(t, err)
+		primary.AssertNumberOfCalls(t, "SendSMS", 2)
+	})
+
+	t.Run("Suppresses recipients providers report as opted out", func(t *testing.T) {
+		module, primary, secondary := newModule(t)
+		primary.On("SendSMS", mock.Anything, mock.Anything).Return(model.SendSMSResponse{}, &model.ProviderError{
+			Provider: "primary", Code: "21610", HTTPStatus: 400, Category: model.ErrorCategoryOptedOut,
+		})
+
+		// The opt-out is neither retried nor failed over
+		_, err := module.SendSMS(ctx, req)
+		require.Error(t, err)
+		assert.Equal(t, model.ErrorCategoryOptedOut, model.ErrorCategoryOf(err))
+		primary.AssertNumberOfCalls(t, "SendSMS", 1)
+		secondary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+
+		entry, ok, err := module.GetSuppressionList().Get(ctx, "+84912345678")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, suppression.ReasonProviderOptOut, entry.Reason)
+		assert.Equal(t, "primary", entry.Provider)
+		assert.Equal(t, "21610", entry.Detail)
+
+		// The next message is stopped before the provider is called
+		_, err = module.SendSMS(ctx, req)
+		assert.ErrorIs(t, err, sms.ErrRecipientSuppressed)
+		primary.AssertNumberOfCalls(t, "SendSMS", 1)
+	})
+
+	t.Run("Feeds the list from opt-out keywords", func(t *testing.T) {
+		module, _, _ := newModule(t)
+		router := webhook.NewKeywordRouter()
+		module.RegisterOptOutKeywords(router)
+
+		require.NoError(t, router.Handle(ctx, model.InboundMessage{Provider: "esms", From: "0912345678", Body: "Stop"}))
+		entry, ok, err := module.GetSuppressionList().Get(ctx, "+84912345678")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, suppression.ReasonOptOut, entry.Reason)
+		assert.Equal(t, "esms", entry.Provider)
+		assert.Equal(t, "STOP", entry.Detail)
+
+		require.NoError(t, router.Handle(ctx, model.InboundMessage{Provider: "esms", From: "84912345678", Body: "START"}))
+		_, ok, err = module.GetSuppressionList().Get(ctx, "+84912345678")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Matches senders without a default region", func(t *testing.T) {
+		configFile, err := createTempConfig(`
+default_provider: primary
+http_timeout: 10s
+retry_attempts: 1
+retry_delay: 1ms
+suppression:
+  enabled: true
+
+providers:
+  primary:
+    api_key: key1
+`)
+		require.NoError(t, err)
+		defer os.Remove(configFile)
+
+		module, err := sms.NewModule(configFile)
+		require.NoError(t, err)
+		primary := new(MockProvider)
+		primary.On("Name").Return("primary")
+		require.NoError(t, module.AddProvider(primary))
+
+		router := webhook.NewKeywordRouter()
+		module.RegisterOptOutKeywords(router)
+
+		// SpeedSMS reports the sender with the country code but without "+"
+		require.NoError(t, router.Handle(ctx, model.InboundMessage{Provider: "speedsms", From: "84912345678", Body: "STOP"}))
+
+		international := req
+		international.Message.To = "+84912345678"
+		_, err = module.SendSMS(ctx, international)
+		assert.ErrorIs(t, err, sms.ErrRecipientSuppressed)
+		primary.AssertNotCalled(t, "SendSMS", mock.Anything, mock.Anything)
+
+		// Numbers that cannot be normalized are not stored under a key sends never match
+		err = router.Handle(ctx, model.InboundMessage{Provider: "speedsms", From: "0912345678", Body: "STOP"})
+		assert.ErrorIs(t, err, model.ErrInvalidPhoneNumber)
+	})
+}
+
+// TestSuppressionConfiguration tests building the suppression list from the configuration
+func TestSuppressionConfiguration(t *testing.T) {
+	newConfig := func(settings config.SuppressionConfig) *config.Config {
+		return &config.Config{
+			DefaultProvider: "primary",
+			HTTPTimeout:     10 * time.Second,
+			SMSTemplate:     "{message}",
+			VoiceTemplate:   "{message}",
+			Providers:       map[string]interface{}{"primary": map[string]interface{}{}},
+			Suppression:     settings,
+		}
+	}
+
+	// Without a list nothing is checked
+	module, err := sms.NewModuleWithConfig(newConfig(config.SuppressionConfig{}))
+	require.NoError(t, err)
+	assert.Nil(t, module.GetSuppressionList())
+	assert.ErrorIs(t, module.Suppress(context.Background(), suppression.Entry{Number: "+84912345678"}), sms.ErrSuppressionDisabled)
+
+	// A file-backed list keeps suppressions across modules
+	path := filepath.Join(t.TempDir(), "suppressed.json")
+	module, err = sms.NewModuleWithConfig(newConfig(config.SuppressionConfig{File: path}))
+	require.NoError(t, err)
+	require.NoError(t, module.Suppress(context.Background(), suppression.Entry{Number: "+84912345678"}))
+
+	module, err = sms.NewModuleWithConfig(newConfig(config.SuppressionConfig{File: path}))
+	require.NoError(t, err)
+	_, ok, err := module.GetSuppressionList().Get(context.Background(), "+84912345678")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// The list can be replaced by the application
+	module.SetSuppressionList(suppression.NewMemory())
+	_, ok, _ = module.GetSuppressionList().Get(context.Background(), "+84912345678")
+	assert.False(t, ok)
+
+	// Keywords must be single words and not both opt-out and opt-in
+	_, err = sms.NewModuleWithConfig(newConfig(config.SuppressionConfig{Enabled: true, OptOutKeywords: []string{"TU CHOI"}}))
+	assert.Error(t, err)
+	_, err = sms.NewModuleWithConfig(newConfig(config.SuppressionConfig{Enabled: true, OptInKeywords: []string{"stop"}}))
+	assert.Error(t, err)
+}
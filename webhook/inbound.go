@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-fork/sms/model"
+)
+
+// InboundParser parses the inbound messages a provider posts to a webhook.
+// Requests that carry no inbound message return no messages.
+type InboundParser interface {
+	ParseInboundMessage(r *http.Request) ([]model.InboundMessage, error)
+}
+
+// InboundParserFunc adapts a function to an InboundParser.
+// The ParseInboundMessage function of each adapter package can be used as one.
+type InboundParserFunc func(r *http.Request) ([]model.InboundMessage, error)
+
+// ParseInboundMessage calls f(r)
+func (f InboundParserFunc) ParseInboundMessage(r *http.Request) ([]model.InboundMessage, error) {
+	return f(r)
+}
+
+// InboundFunc receives a parsed inbound message. The context is that of the webhook request.
+// Returning an error makes the handler answer with a server error, so that providers
+// that retry failed callbacks send the message again.
+type InboundFunc func(ctx context.Context, msg model.InboundMessage) error
+
+// InboundToChannel returns an InboundFunc that sends messages to ch. It waits for the channel
+// to accept each message, and fails if the webhook request ends first.
+func InboundToChannel(ch chan<- model.InboundMessage) InboundFunc {
+	return func(ctx context.Context, msg model.InboundMessage) error {
+		select {
+		case ch <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// InboundHandler is an http.Handler that parses the inbound messages of one provider
+// and passes each message to an InboundFunc, such as the Handle method of a KeywordRouter.
+// Mount one handler per provider, at the URL configured as that provider's inbound webhook.
+type InboundHandler struct {
+	// parser reads the provider's callback format
+	parser InboundParser
+
+	// handle receives the parsed messages
+	handle InboundFunc
+
+	// maxBodySize is the largest request body read
+	maxBodySize int64
+}
+
+// NewInboundHandler creates a handler that parses requests with parser and passes the messages to handle
+func NewInboundHandler(parser InboundParser, handle InboundFunc) *InboundHandler {
+	return &InboundHandler{
+		parser:      parser,
+		handle:      handle,
+		maxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// SetMaxBodySize changes the largest request body the handler reads
+func (h *InboundHandler) SetMaxBodySize(size int64) *InboundHandler {
+	h.maxBodySize = size
+	return h
+}
+
+// ServeHTTP parses a callback and handles its messages. It answers 400 when the request cannot
+// be parsed, 500 when a message could not be handled, and 204 otherwise.
+func (h *InboundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, h.maxBodySize, h.parser.ParseInboundMessage, h.handle,
+		func(msg model.InboundMessage) string {
+			return fmt.Sprintf("failed to handle inbound message from '%s'", msg.From)
+		})
+}
@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-fork/sms/model"
+)
+
+// parseTestInbound reads a message from the "from" and "body" form fields
+func parseTestInbound(r *http.Request) ([]model.InboundMessage, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if r.Form.Get("from") == "" {
+		return nil, fmt.Errorf("%w: from is missing", ErrInvalidPayload)
+	}
+	return []model.InboundMessage{{
+		Provider: "test",
+		From:     r.Form.Get("from"),
+		Body:     r.Form.Get("body"),
+	}}, nil
+}
+
+func postInbound(h http.Handler, from, body string) *httptest.ResponseRecorder {
+	form := url.Values{"from": {from}, "body": {body}}
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/test/inbound", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestInboundHandler(t *testing.T) {
+	ch := make(chan model.InboundMessage, 1)
+	h := NewInboundHandler(InboundParserFunc(parseTestInbound), InboundToChannel(ch))
+
+	if w := postInbound(h, "+84912345678", "YES"); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if msg := <-ch; msg.From != "+84912345678" || msg.Body != "YES" {
+		t.Errorf("Expected the parsed message, got %v", msg)
+	}
+
+	if w := postInbound(h, "", "YES"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid payload, got %d", w.Code)
+	}
+
+	failing := NewInboundHandler(InboundParserFunc(parseTestInbound), func(ctx context.Context, msg model.InboundMessage) error {
+		return errors.New("database unavailable")
+	})
+	if w := postInbound(failing, "+84912345678", "YES"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 when the message cannot be handled, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/webhooks/test/inbound", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for DELETE, got %d", w.Code)
+	}
+}
+
+func TestKeywordRouter(t *testing.T) {
+	var handled []string
+	record := func(name string) InboundFunc {
+		return func(ctx context.Context, msg model.InboundMessage) error {
+			handled = append(handled, name+":"+msg.Body)
+			return nil
+		}
+	}
+
+	router := NewKeywordRouter().
+		On("STOP", record("stop")).
+		On("yes", record("yes"))
+
+	for _, body := range []string{"STOP", "stop.", "  Stop sending me messages", "YES!", "maybe"} {
+		if err := router.Handle(context.Background(), model.InboundMessage{Body: body}); err != nil {
+			t.Fatalf("Expected no error for '%s', got %v", body, err)
+		}
+	}
+	expected := []string{"stop:STOP", "stop:stop.", "stop:  Stop sending me messages", "yes:YES!"}
+	if strings.Join(handled, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, handled)
+	}
+
+	// Unmatched messages go to the default handler once there is one
+	handled = nil
+	router.Default(record("default"))
+	_ = router.Handle(context.Background(), model.InboundMessage{Body: "maybe"})
+	_ = router.Handle(context.Background(), model.InboundMessage{Body: ""})
+	if strings.Join(handled, "|") != "default:maybe|default:" {
+		t.Errorf("Expected the default handler to receive both messages, got %v", handled)
+	}
+
+	// Registering a keyword again replaces its handler
+	handled = nil
+	router.On("Stop", record("stop2"))
+	_ = router.Handle(context.Background(), model.InboundMessage{Body: "STOP"})
+	if len(handled) != 1 || handled[0] != "stop2:STOP" {
+		t.Errorf("Expected the new STOP handler, got %v", handled)
+	}
+}
+
+func TestKeywordRouterErrors(t *testing.T) {
+	errStore := errors.New("store unavailable")
+	router := NewKeywordRouter().On("STOP", func(ctx context.Context, msg model.InboundMessage) error {
+		return errStore
+	})
+
+	err := router.Handle(context.Background(), model.InboundMessage{Body: "stop"})
+	if !errors.Is(err, errStore) {
+		t.Errorf("Expected the handler error, got %v", err)
+	}
+
+	// Routers plug into the inbound handler, which asks the provider to retry
+	h := NewInboundHandler(InboundParserFunc(parseTestInbound), router.Handle)
+	if w := postInbound(h, "+84912345678", "STOP"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if w := postInbound(h, "+84912345678", "hello"); w.Code != http.StatusNoContent {
+		t.Errorf("Expected unmatched messages to be ignored, got %d", w.Code)
+	}
+}
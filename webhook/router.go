@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-fork/sms/model"
+)
+
+// KeywordRouter dispatches inbound messages by their keyword, the first word of the body
+// (see model.InboundMessage.Keyword). Keywords are matched case-insensitively.
+// Its Handle method is an InboundFunc, so a router can be passed to NewInboundHandler.
+type KeywordRouter struct {
+	// mu guards the handlers
+	mu sync.RWMutex
+
+	// handlers maps upper-case keywords to their handlers
+	handlers map[string]InboundFunc
+
+	// fallback handles messages without a registered keyword
+	fallback InboundFunc
+}
+
+// NewKeywordRouter creates a router without handlers
+func NewKeywordRouter() *KeywordRouter {
+	return &KeywordRouter{
+		handlers: make(map[string]InboundFunc),
+	}
+}
+
+// On registers the handler of messages starting with keyword, e.g. router.On("STOP", handleStop).
+// Register synonyms ("UNSUBSCRIBE", "HUY") separately. Registering a keyword again replaces its handler.
+func (r *KeywordRouter) On(keyword string, handler InboundFunc) *KeywordRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[strings.ToUpper(strings.TrimSpace(keyword))] = handler
+	return r
+}
+
+// Default registers the handler of messages whose keyword has no handler.
+// Without one, such messages are ignored.
+func (r *KeywordRouter) Default(handler InboundFunc) *KeywordRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = handler
+	return r
+}
+
+// Handle passes a message to the handler of its keyword, or to the default handler
+func (r *KeywordRouter) Handle(ctx context.Context, msg model.InboundMessage) error {
+	keyword := msg.Keyword()
+
+	r.mu.RLock()
+	handler, ok := r.handlers[keyword]
+	if !ok {
+		handler = r.fallback
+	}
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return nil
+	}
+	if err := handler(ctx, msg); err != nil {
+		return fmt.Errorf("failed to handle keyword '%s': %w", keyword, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-fork/sms/model"
+)
+
+// DefaultMaxBodySize is the largest request body a handler reads (1 MB)
+const DefaultMaxBodySize = 1 << 20
+
+// ErrInvalidPayload is matched by errors.Is when a parser cannot read a callback
+var ErrInvalidPayload = errors.New("invalid webhook payload")
+
+// DeliveryParser parses the delivery reports a provider posts to a webhook.
+// A request may carry several reports; requests without a report return no events.
+type DeliveryParser interface {
+	ParseDeliveryReport(r *http.Request) ([]model.DeliveryEvent, error)
+}
+
+// DeliveryParserFunc adapts a function to a DeliveryParser.
+// The ParseDeliveryReport function of each adapter package can be used as one.
+type DeliveryParserFunc func(r *http.Request) ([]model.DeliveryEvent, error)
+
+// ParseDeliveryReport calls f(r)
+func (f DeliveryParserFunc) ParseDeliveryReport(r *http.Request) ([]model.DeliveryEvent, error) {
+	return f(r)
+}
+
+// DeliveryFunc receives a parsed delivery event. The context is that of the webhook request.
+// Returning an error makes the handler answer with a server error, so that providers
+// that retry failed callbacks send the report again.
+type DeliveryFunc func(ctx context.Context, event model.DeliveryEvent) error
+
+// ToChannel returns a DeliveryFunc that sends events to ch. It waits for the channel
+// to accept each event, and fails if the webhook request ends first.
+func ToChannel(ch chan<- model.DeliveryEvent) DeliveryFunc {
+	return func(ctx context.Context, event model.DeliveryEvent) error {
+		select {
+		case ch <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// DeliveryHandler is an http.Handler that parses the delivery reports of one provider
+// and passes each event to a DeliveryFunc. Mount one handler per provider, at the URL
+// configured as that provider's status callback.
+type DeliveryHandler struct {
+	// parser reads the provider's callback format
+	parser DeliveryParser
+
+	// deliver receives the parsed events
+	deliver DeliveryFunc
+
+	// maxBodySize is the largest request body read
+	maxBodySize int64
+}
+
+// NewDeliveryHandler creates a handler that parses requests with parser and passes the events to deliver
+func NewDeliveryHandler(parser DeliveryParser, deliver DeliveryFunc) *DeliveryHandler {
+	return &DeliveryHandler{
+		parser:      parser,
+		deliver:     deliver,
+		maxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// SetMaxBodySize changes the largest request body the handler reads
+func (h *DeliveryHandler) SetMaxBodySize(size int64) *DeliveryHandler {
+	h.maxBodySize = size
+	return h
+}
+
+// ServeHTTP parses a callback and delivers its events. It answers 400 when the request cannot
+// be parsed, 500 when an event could not be delivered, and 204 otherwise.
+func (h *DeliveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, h.maxBodySize, h.parser.ParseDeliveryReport, h.deliver,
+		func(event model.DeliveryEvent) string {
+			return fmt.Sprintf("failed to handle delivery report for message '%s'", event.MessageID)
+		})
+}
+
+// serveCallback implements the request handling shared by the webhook handlers: it accepts
+// GET and POST requests, limits the body to maxBodySize, parses the items with parse and
+// passes each one to handle. It answers 400 when the request cannot be parsed, 500 with the
+// message returned by failure when an item could not be handled, and 204 otherwise.
+func serveCallback[T any](w http.ResponseWriter, r *http.Request, maxBodySize int64,
+	parse func(r *http.Request) ([]T, error), handle func(ctx context.Context, item T) error,
+	failure func(item T) string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	}
+
+	items, err := parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, item := range items {
+		if err := handle(r.Context(), item); err != nil {
+			http.Error(w, failure(item), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-fork/sms/model"
+)
+
+// parseTestReport reads reports of the form "id=status,id=status"
+func parseTestReport(r *http.Request) ([]model.DeliveryEvent, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	var events []model.DeliveryEvent
+	for _, report := range strings.Split(r.Form.Get("reports"), ",") {
+		id, status, ok := strings.Cut(report, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed report '%s'", ErrInvalidPayload, report)
+		}
+		events = append(events, model.DeliveryEvent{
+			Provider:  "test",
+			MessageID: id,
+			Status:    model.MessageStatus(status),
+			RawStatus: status,
+		})
+	}
+	return events, nil
+}
+
+func postReports(h http.Handler, reports string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/test", strings.NewReader("reports="+reports))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeliveryHandler(t *testing.T) {
+	var events []model.DeliveryEvent
+	h := NewDeliveryHandler(DeliveryParserFunc(parseTestReport), func(ctx context.Context, event model.DeliveryEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	w := postReports(h, "msg-1=delivered,msg-2=failed")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if len(events) != 2 || events[0].MessageID != "msg-1" || events[1].Status != model.StatusFailed {
+		t.Errorf("Expected both reports to be delivered in order, got %v", events)
+	}
+
+	if w := postReports(h, "garbage"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid payload, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/webhooks/test", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for PUT, got %d", w.Code)
+	}
+}
+
+func TestDeliveryHandlerErrors(t *testing.T) {
+	var delivered []string
+	h := NewDeliveryHandler(DeliveryParserFunc(parseTestReport), func(ctx context.Context, event model.DeliveryEvent) error {
+		if event.MessageID == "msg-2" {
+			return errors.New("database unavailable")
+		}
+		delivered = append(delivered, event.MessageID)
+		return nil
+	})
+
+	// The provider is asked to send the report again
+	w := postReports(h, "msg-1=delivered,msg-2=delivered,msg-3=delivered")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+	if len(delivered) != 1 {
+		t.Errorf("Expected delivery to stop at the failed event, got %v", delivered)
+	}
+}
+
+func TestDeliveryHandlerBodySize(t *testing.T) {
+	h := NewDeliveryHandler(DeliveryParserFunc(parseTestReport), func(ctx context.Context, event model.DeliveryEvent) error {
+		return nil
+	}).SetMaxBodySize(16)
+
+	if w := postReports(h, "msg-1=delivered,msg-2=delivered"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an oversized body, got %d", w.Code)
+	}
+}
+
+func TestToChannel(t *testing.T) {
+	ch := make(chan model.DeliveryEvent, 1)
+	deliver := ToChannel(ch)
+
+	if err := deliver(context.Background(), model.DeliveryEvent{MessageID: "msg-1"}); err != nil {
+		t.Fatalf("Expected the event to be sent, got %v", err)
+	}
+	if event := <-ch; event.MessageID != "msg-1" {
+		t.Errorf("Expected msg-1, got %s", event.MessageID)
+	}
+
+	// A full channel fails once the request ends
+	ch <- model.DeliveryEvent{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := deliver(ctx, model.DeliveryEvent{MessageID: "msg-2"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}